@@ -0,0 +1,150 @@
+// tweetlib - A fully oauth-authenticated Go Twitter library
+//
+// Copyright 2011 The Tweetlib Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tweetlib
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+)
+
+// tokenURL and invalidateTokenURL are vars, not consts, so tests can
+// point them at a local httptest.Server instead of Twitter.
+var (
+	tokenURL           = "https://api.twitter.com/oauth2/token"
+	invalidateTokenURL = "https://api.twitter.com/oauth2/invalidate_token"
+)
+
+// AppAuth implements Twitter's application-only authentication, which
+// lets a Client call read-only endpoints (search, user_timeline,
+// statuses/show, etc.) without a user context.
+// See https://dev.twitter.com/oauth/application-only
+type AppAuth struct {
+	consumerKey    string
+	consumerSecret string
+	client         *http.Client
+
+	mu    sync.Mutex
+	token string
+}
+
+// NewAppAuth creates an AppAuth from a consumer key/secret pair. No
+// network call is made until a bearer token is actually needed.
+func NewAppAuth(consumerKey, consumerSecret string) *AppAuth {
+	return &AppAuth{
+		consumerKey:    consumerKey,
+		consumerSecret: consumerSecret,
+		client:         http.DefaultClient,
+	}
+}
+
+// Token returns the current bearer token, fetching a new one from
+// Twitter on first use or after InvalidateToken.
+func (a *AppAuth) Token() (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.token != "" {
+		return a.token, nil
+	}
+	return a.fetchTokenLocked()
+}
+
+// fetchTokenLocked requests a new bearer token. a.mu must be held.
+func (a *AppAuth) fetchTokenLocked() (string, error) {
+	req, err := http.NewRequest("POST", tokenURL, strings.NewReader("grant_type=client_credentials"))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Basic "+a.basicCredentials())
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded;charset=UTF-8")
+	res, err := a.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+	slurp, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return "", err
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		return "", fmt.Errorf("tweetlib: failed to obtain bearer token: %s", slurp)
+	}
+	var reply struct {
+		TokenType   string `json:"token_type"`
+		AccessToken string `json:"access_token"`
+	}
+	if err = json.Unmarshal(slurp, &reply); err != nil {
+		return "", err
+	}
+	if reply.AccessToken == "" {
+		return "", errors.New("tweetlib: oauth2/token reply did not include an access token")
+	}
+	a.token = reply.AccessToken
+	return a.token, nil
+}
+
+// InvalidateToken revokes the current bearer token, if any, and
+// clears it so the next call to Token fetches a fresh one.
+// See https://dev.twitter.com/oauth/reference/post/oauth2/invalidate_token
+func (a *AppAuth) InvalidateToken() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.token == "" {
+		return nil
+	}
+	body := strings.NewReader("access_token=" + url.QueryEscape(a.token))
+	req, err := http.NewRequest("POST", invalidateTokenURL, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Basic "+a.basicCredentials())
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded;charset=UTF-8")
+	res, err := a.client.Do(req)
+	if err != nil {
+		return err
+	}
+	res.Body.Close()
+	a.token = ""
+	return nil
+}
+
+func (a *AppAuth) basicCredentials() string {
+	raw := url.QueryEscape(a.consumerKey) + ":" + url.QueryEscape(a.consumerSecret)
+	return base64.StdEncoding.EncodeToString([]byte(raw))
+}
+
+// NewAppClient creates a Client that authenticates using
+// application-only (bearer token) auth instead of user OAuth. The
+// resulting Client can only call read-only (GET) endpoints; POST
+// calls that require a user context fail with an error before any
+// request is made.
+func NewAppClient(appAuth *AppAuth) (*Client, error) {
+	if appAuth == nil {
+		return nil, errors.New("tweetlib: appAuth is nil")
+	}
+	c := &Client{client: appAuth.client, appAuth: appAuth}
+	c.Streams = &StreamService{client: c}
+	c.Media = &MediaService{client: c}
+	return c, nil
+}
+
+// setBearerAuth attaches the current bearer token to req, fetching
+// one first if necessary.
+func (c *Client) setBearerAuth(req *http.Request) error {
+	token, err := c.appAuth.Token()
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	return nil
+}
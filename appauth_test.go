@@ -0,0 +1,105 @@
+// tweetlib - A fully oauth-authenticated Go Twitter library
+//
+// Copyright 2011 The Tweetlib Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tweetlib
+
+import (
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestBasicCredentialsEncodesKeyAndSecret(t *testing.T) {
+	a := NewAppAuth("a key", "a/secret")
+	got, err := base64.StdEncoding.DecodeString(a.basicCredentials())
+	if err != nil {
+		t.Fatalf("basicCredentials did not produce valid base64: %v", err)
+	}
+	if want := "a+key:a%2Fsecret"; string(got) != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestTokenReturnsCachedTokenWithoutANetworkCall(t *testing.T) {
+	a := NewAppAuth("key", "secret")
+	a.token = "cached-token"
+	tok, err := a.Token()
+	if err != nil {
+		t.Fatalf("Token: %v", err)
+	}
+	if tok != "cached-token" {
+		t.Fatalf("got %q, want %q", tok, "cached-token")
+	}
+}
+
+func TestTokenFetchesAndCachesANewToken(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if got := r.Header.Get("Authorization"); !strings.HasPrefix(got, "Basic ") {
+			t.Errorf("missing Basic auth header, got %q", got)
+		}
+		w.Write([]byte(`{"token_type":"bearer","access_token":"fresh-token"}`))
+	}))
+	defer srv.Close()
+	oldURL := tokenURL
+	tokenURL = srv.URL
+	defer func() { tokenURL = oldURL }()
+
+	a := NewAppAuth("key", "secret")
+	tok, err := a.Token()
+	if err != nil {
+		t.Fatalf("Token: %v", err)
+	}
+	if tok != "fresh-token" {
+		t.Fatalf("got %q, want %q", tok, "fresh-token")
+	}
+	if a.token != "fresh-token" {
+		t.Fatalf("Token did not cache the fetched token")
+	}
+}
+
+func TestTokenSurfacesAnErrorReply(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+		w.Write([]byte(`{"errors":[{"code":99,"message":"Invalid consumer key"}]}`))
+	}))
+	defer srv.Close()
+	oldURL := tokenURL
+	tokenURL = srv.URL
+	defer func() { tokenURL = oldURL }()
+
+	a := NewAppAuth("bad", "bad")
+	if _, err := a.Token(); err == nil {
+		t.Fatal("expected an error for a non-2xx token reply")
+	}
+}
+
+func TestInvalidateTokenIsANoopWhenThereIsNoToken(t *testing.T) {
+	a := NewAppAuth("key", "secret")
+	if err := a.InvalidateToken(); err != nil {
+		t.Fatalf("InvalidateToken with no token: %v", err)
+	}
+}
+
+func TestInvalidateTokenClearsTheCachedToken(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+	oldURL := invalidateTokenURL
+	invalidateTokenURL = srv.URL
+	defer func() { invalidateTokenURL = oldURL }()
+
+	a := NewAppAuth("key", "secret")
+	a.token = "stale-token"
+	if err := a.InvalidateToken(); err != nil {
+		t.Fatalf("InvalidateToken: %v", err)
+	}
+	if a.token != "" {
+		t.Fatalf("token not cleared, got %q", a.token)
+	}
+}
@@ -0,0 +1,340 @@
+// tweetlib - A fully oauth-authenticated Go Twitter library
+//
+// Copyright 2011 The Tweetlib Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tweetlib
+
+import "context"
+
+// clone returns a copy of o so a cursor can add a per-page "cursor" or
+// "max_id" parameter without mutating the Optionals the caller passed in.
+func (o *Optionals) clone() *Optionals {
+	c := NewOptionals()
+	for k, v := range o.Values {
+		vv := make([]string, len(v))
+		copy(vv, v)
+		c.Values[k] = vv
+	}
+	return c
+}
+
+// userCursorReply is the shape shared by friends/list and
+// followers/list.
+type userCursorReply struct {
+	Users          []User `json:"users"`
+	NextCursor     int64  `json:"next_cursor"`
+	PreviousCursor int64  `json:"previous_cursor"`
+}
+
+// idCursorReply is the shape shared by friends/ids and followers/ids.
+type idCursorReply struct {
+	Ids            []int64 `json:"ids"`
+	NextCursor     int64   `json:"next_cursor"`
+	PreviousCursor int64   `json:"previous_cursor"`
+}
+
+// UserCursor walks a cursored, user-returning endpoint such as
+// friends/list or followers/list one page at a time. A zero value is
+// never useful; obtain one from Client.Friends or Client.Followers.
+type UserCursor struct {
+	client   *Client
+	endpoint string
+	opts     *Optionals
+
+	cursor int64
+	done   bool
+}
+
+// Friends returns a cursor over the users screenname follows.
+// See https://dev.twitter.com/docs/api/1.1/get/friends/list
+func (c *Client) Friends(screenname string, opts *Optionals) *UserCursor {
+	return c.newUserCursor("friends/list", screenname, opts)
+}
+
+// Followers returns a cursor over the users following screenname.
+// See https://dev.twitter.com/docs/api/1.1/get/followers/list
+func (c *Client) Followers(screenname string, opts *Optionals) *UserCursor {
+	return c.newUserCursor("followers/list", screenname, opts)
+}
+
+func (c *Client) newUserCursor(endpoint, screenname string, opts *Optionals) *UserCursor {
+	if opts == nil {
+		opts = NewOptionals()
+	}
+	opts.Add("screen_name", screenname)
+	return &UserCursor{client: c, endpoint: endpoint, opts: opts, cursor: -1}
+}
+
+// Next fetches the next page. hasMore is false once the last page has
+// been returned; calling Next again after that returns an empty page
+// and hasMore == false without making a request.
+func (uc *UserCursor) Next() (page []User, hasMore bool, err error) {
+	return uc.NextContext(context.Background())
+}
+
+// NextContext is Next with a context.Context that governs the single
+// underlying request.
+func (uc *UserCursor) NextContext(ctx context.Context) (page []User, hasMore bool, err error) {
+	if uc.done {
+		return nil, false, nil
+	}
+	opts := uc.opts.clone()
+	opts.Add("cursor", uc.cursor)
+	var reply userCursorReply
+	if err = uc.client.CallContext(ctx, "GET", uc.endpoint, opts, &reply); err != nil {
+		uc.done = true
+		return nil, false, err
+	}
+	uc.cursor = reply.NextCursor
+	uc.done = reply.NextCursor == 0
+	return reply.Users, !uc.done, nil
+}
+
+// All walks every page and returns the combined results. It stops and
+// returns the error as soon as a page fails.
+func (uc *UserCursor) All() ([]User, error) {
+	return uc.AllContext(context.Background())
+}
+
+// AllContext is All with a context.Context that governs every
+// underlying request; once ctx is done, All stops and returns what it
+// has collected so far along with the error of the request ctx ended.
+func (uc *UserCursor) AllContext(ctx context.Context) ([]User, error) {
+	var all []User
+	for {
+		page, hasMore, err := uc.NextContext(ctx)
+		if err != nil {
+			return all, err
+		}
+		all = append(all, page...)
+		if !hasMore {
+			return all, nil
+		}
+	}
+}
+
+// Each calls fn for every user across every page, stopping early if
+// fn returns false or a page fails to load.
+func (uc *UserCursor) Each(fn func(User) bool) error {
+	return uc.EachContext(context.Background(), fn)
+}
+
+// EachContext is Each with a context.Context that governs every
+// underlying request.
+func (uc *UserCursor) EachContext(ctx context.Context, fn func(User) bool) error {
+	for {
+		page, hasMore, err := uc.NextContext(ctx)
+		if err != nil {
+			return err
+		}
+		for _, u := range page {
+			if !fn(u) {
+				return nil
+			}
+		}
+		if !hasMore {
+			return nil
+		}
+	}
+}
+
+// IDCursor walks a cursored, ID-returning endpoint such as
+// friends/ids or followers/ids one page at a time. Obtain one from
+// Client.FriendIDs or Client.FollowerIDs.
+type IDCursor struct {
+	client   *Client
+	endpoint string
+	opts     *Optionals
+
+	cursor int64
+	done   bool
+}
+
+// FriendIDs returns a cursor over the user IDs screenname follows.
+// See https://dev.twitter.com/docs/api/1.1/get/friends/ids
+func (c *Client) FriendIDs(screenname string, opts *Optionals) *IDCursor {
+	return c.newIDCursor("friends/ids", screenname, opts)
+}
+
+// FollowerIDs returns a cursor over the user IDs following screenname.
+// See https://dev.twitter.com/docs/api/1.1/get/followers/ids
+func (c *Client) FollowerIDs(screenname string, opts *Optionals) *IDCursor {
+	return c.newIDCursor("followers/ids", screenname, opts)
+}
+
+func (c *Client) newIDCursor(endpoint, screenname string, opts *Optionals) *IDCursor {
+	if opts == nil {
+		opts = NewOptionals()
+	}
+	opts.Add("screen_name", screenname)
+	return &IDCursor{client: c, endpoint: endpoint, opts: opts, cursor: -1}
+}
+
+// Next fetches the next page of IDs.
+func (ic *IDCursor) Next() (page []int64, hasMore bool, err error) {
+	return ic.NextContext(context.Background())
+}
+
+// NextContext is Next with a context.Context that governs the single
+// underlying request.
+func (ic *IDCursor) NextContext(ctx context.Context) (page []int64, hasMore bool, err error) {
+	if ic.done {
+		return nil, false, nil
+	}
+	opts := ic.opts.clone()
+	opts.Add("cursor", ic.cursor)
+	var reply idCursorReply
+	if err = ic.client.CallContext(ctx, "GET", ic.endpoint, opts, &reply); err != nil {
+		ic.done = true
+		return nil, false, err
+	}
+	ic.cursor = reply.NextCursor
+	ic.done = reply.NextCursor == 0
+	return reply.Ids, !ic.done, nil
+}
+
+// All walks every page and returns the combined IDs.
+func (ic *IDCursor) All() ([]int64, error) {
+	return ic.AllContext(context.Background())
+}
+
+// AllContext is All with a context.Context that governs every
+// underlying request.
+func (ic *IDCursor) AllContext(ctx context.Context) ([]int64, error) {
+	var all []int64
+	for {
+		page, hasMore, err := ic.NextContext(ctx)
+		if err != nil {
+			return all, err
+		}
+		all = append(all, page...)
+		if !hasMore {
+			return all, nil
+		}
+	}
+}
+
+// Each calls fn for every ID across every page, stopping early if fn
+// returns false or a page fails to load.
+func (ic *IDCursor) Each(fn func(int64) bool) error {
+	return ic.EachContext(context.Background(), fn)
+}
+
+// EachContext is Each with a context.Context that governs every
+// underlying request.
+func (ic *IDCursor) EachContext(ctx context.Context, fn func(int64) bool) error {
+	for {
+		page, hasMore, err := ic.NextContext(ctx)
+		if err != nil {
+			return err
+		}
+		for _, id := range page {
+			if !fn(id) {
+				return nil
+			}
+		}
+		if !hasMore {
+			return nil
+		}
+	}
+}
+
+// TimelineCursor walks a statuses/*_timeline endpoint backward in
+// time, each successive page older than the last, by threading
+// max_id. Obtain one from Client.UserTimelineCursor.
+type TimelineCursor struct {
+	client   *Client
+	endpoint string
+	opts     *Optionals
+
+	maxId int64
+	done  bool
+}
+
+// UserTimelineCursor returns a cursor that walks screenname's timeline
+// backward from the most recent tweet.
+// See https://dev.twitter.com/docs/api/1.1/get/statuses/user_timeline
+func (c *Client) UserTimelineCursor(screenname string, opts *Optionals) *TimelineCursor {
+	if opts == nil {
+		opts = NewOptionals()
+	}
+	opts.Add("screen_name", screenname)
+	return &TimelineCursor{client: c, endpoint: "statuses/user_timeline", opts: opts}
+}
+
+// Next fetches the next, older page of tweets.
+func (tc *TimelineCursor) Next() (page []Tweet, hasMore bool, err error) {
+	return tc.NextContext(context.Background())
+}
+
+// NextContext is Next with a context.Context that governs the single
+// underlying request.
+func (tc *TimelineCursor) NextContext(ctx context.Context) (page []Tweet, hasMore bool, err error) {
+	if tc.done {
+		return nil, false, nil
+	}
+	opts := tc.opts.clone()
+	if tc.maxId != 0 {
+		opts.Add("max_id", tc.maxId)
+	}
+	var tweets TweetList
+	if err = tc.client.CallContext(ctx, "GET", tc.endpoint, opts, &tweets); err != nil {
+		tc.done = true
+		return nil, false, err
+	}
+	if len(tweets) == 0 {
+		tc.done = true
+		return nil, false, nil
+	}
+	tc.maxId = tweets[len(tweets)-1].Id - 1
+	return tweets, true, nil
+}
+
+// All walks the timeline until it is exhausted and returns every
+// tweet seen, oldest page last.
+func (tc *TimelineCursor) All() ([]Tweet, error) {
+	return tc.AllContext(context.Background())
+}
+
+// AllContext is All with a context.Context that governs every
+// underlying request.
+func (tc *TimelineCursor) AllContext(ctx context.Context) ([]Tweet, error) {
+	var all []Tweet
+	for {
+		page, hasMore, err := tc.NextContext(ctx)
+		if err != nil {
+			return all, err
+		}
+		all = append(all, page...)
+		if !hasMore {
+			return all, nil
+		}
+	}
+}
+
+// Each calls fn for every tweet, oldest-page-last, stopping early if
+// fn returns false or a page fails to load.
+func (tc *TimelineCursor) Each(fn func(Tweet) bool) error {
+	return tc.EachContext(context.Background(), fn)
+}
+
+// EachContext is Each with a context.Context that governs every
+// underlying request.
+func (tc *TimelineCursor) EachContext(ctx context.Context, fn func(Tweet) bool) error {
+	for {
+		page, hasMore, err := tc.NextContext(ctx)
+		if err != nil {
+			return err
+		}
+		for _, t := range page {
+			if !fn(t) {
+				return nil
+			}
+		}
+		if !hasMore {
+			return nil
+		}
+	}
+}
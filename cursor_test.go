@@ -0,0 +1,136 @@
+// tweetlib - A fully oauth-authenticated Go Twitter library
+//
+// Copyright 2011 The Tweetlib Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tweetlib
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func newTestCursorClient(t *testing.T, handler http.HandlerFunc) (*Client, func()) {
+	srv := httptest.NewServer(handler)
+	c := &Client{client: srv.Client(), baseURL: srv.URL}
+	return c, srv.Close
+}
+
+func TestUserCursorWalksUntilNextCursorIsZero(t *testing.T) {
+	pages := []string{
+		`{"users":[{"id":1}],"next_cursor":2}`,
+		`{"users":[{"id":2}],"next_cursor":0}`,
+	}
+	calls := 0
+	c, closeSrv := newTestCursorClient(t, func(w http.ResponseWriter, r *http.Request) {
+		if calls >= len(pages) {
+			t.Fatalf("unexpected extra request, cursor=%s", r.URL.Query().Get("cursor"))
+		}
+		w.Write([]byte(pages[calls]))
+		calls++
+	})
+	defer closeSrv()
+
+	uc := c.newUserCursor("followers/list", "someuser", nil)
+	all, err := uc.All()
+	if err != nil {
+		t.Fatalf("All: %v", err)
+	}
+	if len(all) != 2 || all[0].Id != 1 || all[1].Id != 2 {
+		t.Fatalf("got %+v, want two users with Id 1 and 2", all)
+	}
+	if calls != 2 {
+		t.Fatalf("got %d requests, want 2", calls)
+	}
+
+	page, hasMore, err := uc.Next()
+	if err != nil || hasMore || page != nil {
+		t.Fatalf("Next after done: got page=%v hasMore=%v err=%v, want nil/false/nil", page, hasMore, err)
+	}
+}
+
+func TestIDCursorWalksUntilNextCursorIsZero(t *testing.T) {
+	pages := []string{
+		`{"ids":[10,11],"next_cursor":5}`,
+		`{"ids":[12],"next_cursor":0}`,
+	}
+	calls := 0
+	c, closeSrv := newTestCursorClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(pages[calls]))
+		calls++
+	})
+	defer closeSrv()
+
+	ic := c.newIDCursor("friends/ids", "someuser", nil)
+	all, err := ic.All()
+	if err != nil {
+		t.Fatalf("All: %v", err)
+	}
+	if len(all) != 3 || all[0] != 10 || all[1] != 11 || all[2] != 12 {
+		t.Fatalf("got %v, want [10 11 12]", all)
+	}
+}
+
+func TestTimelineCursorStopsOnAnEmptyPage(t *testing.T) {
+	pages := []string{
+		`[{"id":100},{"id":99}]`,
+		`[]`,
+	}
+	var maxIds []string
+	calls := 0
+	c, closeSrv := newTestCursorClient(t, func(w http.ResponseWriter, r *http.Request) {
+		maxIds = append(maxIds, r.URL.Query().Get("max_id"))
+		w.Write([]byte(pages[calls]))
+		calls++
+	})
+	defer closeSrv()
+
+	tc := c.UserTimelineCursor("someuser", nil)
+	all, err := tc.All()
+	if err != nil {
+		t.Fatalf("All: %v", err)
+	}
+	if len(all) != 2 || all[0].Id != 100 || all[1].Id != 99 {
+		t.Fatalf("got %+v, want two tweets with Id 100 and 99", all)
+	}
+	if calls != 2 {
+		t.Fatalf("got %d requests, want 2", calls)
+	}
+	if maxIds[0] != "" {
+		t.Fatalf("first page should not send max_id, got %q", maxIds[0])
+	}
+	if maxIds[1] != "98" {
+		t.Fatalf("second page should send max_id=98 (oldest Id - 1), got %q", maxIds[1])
+	}
+}
+
+func TestEachStopsEarlyWhenFnReturnsFalse(t *testing.T) {
+	pages := []string{
+		`{"users":[{"id":1},{"id":2}],"next_cursor":2}`,
+		`{"users":[{"id":3}],"next_cursor":0}`,
+	}
+	calls := 0
+	c, closeSrv := newTestCursorClient(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(pages[calls]))
+		calls++
+	})
+	defer closeSrv()
+
+	uc := c.newUserCursor("followers/list", "someuser", nil)
+	var seen []int64
+	err := uc.Each(func(u User) bool {
+		seen = append(seen, u.Id)
+		return len(seen) < 1
+	})
+	if err != nil {
+		t.Fatalf("Each: %v", err)
+	}
+	if len(seen) != 1 || seen[0] != 1 {
+		t.Fatalf("got %v, want Each to stop after the first user", seen)
+	}
+	if calls != 1 {
+		t.Fatalf("got %d requests, want Each to stop before fetching a second page", calls)
+	}
+}
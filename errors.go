@@ -0,0 +1,106 @@
+// tweetlib - A fully oauth-authenticated Go Twitter library
+//
+// Copyright 2011 The Tweetlib Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tweetlib
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// APIError is returned by Call, CallJSON and the endpoint wrappers
+// whenever Twitter's API responds with an error. It preserves the
+// individual errors Twitter sent back (message + numeric code, see
+// https://dev.twitter.com/overview/api/response-codes), the HTTP
+// status and the rate-limit headers of the response, so callers can
+// tell a duplicate tweet (187) apart from a rate limit (88) or an
+// invalid token (89) instead of pattern-matching a flattened string.
+// An error response whose body is not valid JSON is not turned into an
+// *APIError; the JSON decoding error is returned instead.
+type APIError struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int
+	// Errors holds the individual errors Twitter reported.
+	Errors []twitterError
+
+	// RateLimit is the quota for the endpoint that was called, as
+	// reported in the x-rate-limit-* response headers. Limit is 0
+	// when Twitter did not send rate-limit headers (e.g. for errors
+	// unrelated to rate limiting).
+	RateLimit RateLimit
+}
+
+// RateLimit describes the rate-limit window Twitter reported for a
+// request, via the x-rate-limit-limit, x-rate-limit-remaining and
+// x-rate-limit-reset headers.
+// See https://dev.twitter.com/docs/rate-limiting/1.1
+type RateLimit struct {
+	Limit     int
+	Remaining int
+	Reset     time.Time
+}
+
+func (e *APIError) Error() string {
+	buf := bytes.NewBufferString("")
+	for i := range e.Errors {
+		fmt.Fprintf(buf, "%s (%d)\n", e.Errors[i].Message, e.Errors[i].Code)
+	}
+	return buf.String()
+}
+
+// HasCode reports whether err is an *APIError containing the given
+// Twitter error code.
+func HasCode(err error, code int) bool {
+	apiErr, ok := err.(*APIError)
+	if !ok {
+		return false
+	}
+	for _, e := range apiErr.Errors {
+		if e.Code == code {
+			return true
+		}
+	}
+	return false
+}
+
+// IsRateLimited reports whether err is an *APIError caused by Twitter
+// rate-limiting the request (HTTP 429, or error code 88).
+func IsRateLimited(err error) bool {
+	apiErr, ok := err.(*APIError)
+	if !ok {
+		return false
+	}
+	return apiErr.StatusCode == http.StatusTooManyRequests || HasCode(err, 88)
+}
+
+// RetryAfter returns how long a caller should wait before retrying a
+// rate-limited request, based on the x-rate-limit-reset header. It
+// returns 0 if err is not a rate-limit *APIError or carries no reset
+// time.
+func RetryAfter(err error) time.Duration {
+	apiErr, ok := err.(*APIError)
+	if !ok || !IsRateLimited(err) || apiErr.RateLimit.Reset.IsZero() {
+		return 0
+	}
+	if d := time.Until(apiErr.RateLimit.Reset); d > 0 {
+		return d
+	}
+	return 0
+}
+
+// parseRateLimit reads the x-rate-limit-* headers off a response.
+func parseRateLimit(res *http.Response) RateLimit {
+	var rl RateLimit
+	rl.Limit, _ = strconv.Atoi(res.Header.Get("x-rate-limit-limit"))
+	rl.Remaining, _ = strconv.Atoi(res.Header.Get("x-rate-limit-remaining"))
+	if secs, err := strconv.ParseInt(res.Header.Get("x-rate-limit-reset"), 10, 64); err == nil {
+		rl.Reset = time.Unix(secs, 0)
+	}
+	return rl
+}
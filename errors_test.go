@@ -0,0 +1,101 @@
+// tweetlib - A fully oauth-authenticated Go Twitter library
+//
+// Copyright 2011 The Tweetlib Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tweetlib
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestAPIErrorFormatsMessageAndCode(t *testing.T) {
+	err := &APIError{Errors: []twitterError{
+		{Message: "Status is a duplicate.", Code: 187},
+		{Message: "Rate limit exceeded.", Code: 88},
+	}}
+	want := "Status is a duplicate. (187)\nRate limit exceeded. (88)\n"
+	if got := err.Error(); got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
+
+func TestHasCode(t *testing.T) {
+	err := &APIError{Errors: []twitterError{{Message: "duplicate", Code: 187}}}
+	if !HasCode(err, 187) {
+		t.Fatal("expected HasCode to find 187")
+	}
+	if HasCode(err, 88) {
+		t.Fatal("did not expect HasCode to find 88")
+	}
+	if HasCode(nil, 187) {
+		t.Fatal("HasCode on a nil error should be false")
+	}
+}
+
+func TestIsRateLimited(t *testing.T) {
+	cases := []struct {
+		name string
+		err  *APIError
+		want bool
+	}{
+		{"429 status", &APIError{StatusCode: http.StatusTooManyRequests}, true},
+		{"code 88", &APIError{StatusCode: http.StatusOK, Errors: []twitterError{{Code: 88}}}, true},
+		{"unrelated error", &APIError{StatusCode: http.StatusNotFound, Errors: []twitterError{{Code: 34}}}, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := IsRateLimited(tc.err); got != tc.want {
+				t.Fatalf("got %v, want %v", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	future := time.Now().Add(time.Minute)
+	limited := &APIError{StatusCode: http.StatusTooManyRequests, RateLimit: RateLimit{Reset: future}}
+	if d := RetryAfter(limited); d <= 0 || d > time.Minute {
+		t.Fatalf("got %s, want something in (0, 1m]", d)
+	}
+
+	notLimited := &APIError{StatusCode: http.StatusNotFound}
+	if d := RetryAfter(notLimited); d != 0 {
+		t.Fatalf("got %s, want 0 for a non-rate-limit error", d)
+	}
+
+	past := &APIError{StatusCode: http.StatusTooManyRequests, RateLimit: RateLimit{Reset: time.Now().Add(-time.Minute)}}
+	if d := RetryAfter(past); d != 0 {
+		t.Fatalf("got %s, want 0 once the reset time has passed", d)
+	}
+}
+
+func TestParseRateLimit(t *testing.T) {
+	w := httptest.NewRecorder()
+	w.Header().Set("x-rate-limit-limit", "180")
+	w.Header().Set("x-rate-limit-remaining", "42")
+	resetAt := time.Now().Add(10 * time.Minute).Truncate(time.Second)
+	w.Header().Set("x-rate-limit-reset", strconv.FormatInt(resetAt.Unix(), 10))
+	res := w.Result()
+
+	rl := parseRateLimit(res)
+	if rl.Limit != 180 || rl.Remaining != 42 {
+		t.Fatalf("got %+v, want Limit=180 Remaining=42", rl)
+	}
+	if !rl.Reset.Equal(resetAt) {
+		t.Fatalf("got Reset=%s, want %s", rl.Reset, resetAt)
+	}
+}
+
+func TestParseRateLimitWithNoHeaders(t *testing.T) {
+	res := httptest.NewRecorder().Result()
+	rl := parseRateLimit(res)
+	if rl.Limit != 0 || rl.Remaining != 0 || !rl.Reset.IsZero() {
+		t.Fatalf("got %+v, want zero value", rl)
+	}
+}
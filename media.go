@@ -0,0 +1,273 @@
+// tweetlib - A fully oauth-authenticated Go Twitter library
+//
+// Copyright 2011 The Tweetlib Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tweetlib
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime/multipart"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// mediaUploadURL is a var, not a const, so tests can point it at a
+// local httptest.Server instead of Twitter.
+var mediaUploadURL = "https://upload.twitter.com/1.1/media/upload.json"
+
+// chunkSize is the size of each APPEND segment. Twitter accepts
+// segments up to 5MB.
+const chunkSize = 5 * 1024 * 1024
+
+// MediaService implements Twitter's chunked media upload protocol
+// (INIT/APPEND/FINALIZE/STATUS) and is exposed as Client.Media.
+// See https://dev.twitter.com/rest/reference/post/media/upload-chunked
+type MediaService struct {
+	client *Client
+}
+
+// MediaUploadResult is what Upload returns once a media item has
+// finished uploading and, if necessary, processing.
+type MediaUploadResult struct {
+	MediaId        int64
+	MediaIdString  string
+	ProcessingInfo *ProcessingInfo
+}
+
+// ProcessingInfo reports FINALIZE/STATUS's view of asynchronous media
+// processing, used for video and other large uploads.
+type ProcessingInfo struct {
+	State           string `json:"state"`
+	ProgressPercent int    `json:"progress_percent"`
+	CheckAfterSecs  int    `json:"check_after_secs"`
+	Error           *struct {
+		Code    int    `json:"code"`
+		Name    string `json:"name"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// mediaReply mirrors the subset of Twitter's media/upload.json JSON
+// replies (INIT, FINALIZE and STATUS) tweetlib cares about.
+type mediaReply struct {
+	MediaId          int64           `json:"media_id"`
+	MediaIdString    string          `json:"media_id_string"`
+	ExpiresAfterSecs int             `json:"expires_after_secs"`
+	ProcessingInfo   *ProcessingInfo `json:"processing_info"`
+}
+
+// Upload streams r to Twitter using the chunked upload protocol and
+// blocks until the media item is ready to be attached to a tweet, i.e.
+// until FINALIZE (and, for media that requires it, STATUS polling)
+// reports success. mediaType is the MIME type of the contents of r
+// (e.g. "video/mp4", "image/jpeg"); category is one of
+// "tweet_image", "tweet_gif" or "tweet_video". Up to four image media
+// IDs or one video/gif media ID may be attached to a single tweet via
+// opts.Add("media_ids", "id1,id2") on UpdateStatus.
+func (m *MediaService) Upload(r io.Reader, mediaType, category string, totalBytes int64) (*MediaUploadResult, error) {
+	return m.UploadContext(context.Background(), r, mediaType, category, totalBytes)
+}
+
+// UploadContext is Upload with a context.Context that governs the
+// whole upload: every APPEND segment and every STATUS poll. Canceling
+// ctx partway through a large video upload stops it at the next
+// segment or poll instead of running to completion.
+func (m *MediaService) UploadContext(ctx context.Context, r io.Reader, mediaType, category string, totalBytes int64) (*MediaUploadResult, error) {
+	mediaId, err := m.init(ctx, totalBytes, mediaType, category)
+	if err != nil {
+		return nil, err
+	}
+	if err = m.appendAll(ctx, mediaId, r); err != nil {
+		return nil, err
+	}
+	reply, err := m.finalize(ctx, mediaId)
+	if err != nil {
+		return nil, err
+	}
+	info := reply.ProcessingInfo
+	if info != nil {
+		if info, err = m.awaitProcessing(ctx, mediaId, info); err != nil {
+			return nil, err
+		}
+	}
+	return &MediaUploadResult{
+		MediaId:        mediaId,
+		MediaIdString:  strconv.FormatInt(mediaId, 10),
+		ProcessingInfo: info,
+	}, nil
+}
+
+// init performs the INIT command, declaring the upload up front so
+// Twitter can validate its size and type before any bytes are sent.
+func (m *MediaService) init(ctx context.Context, totalBytes int64, mediaType, category string) (int64, error) {
+	opts := NewOptionals()
+	opts.Add("command", "INIT")
+	opts.Add("total_bytes", totalBytes)
+	opts.Add("media_type", mediaType)
+	opts.Add("media_category", category)
+	var reply mediaReply
+	if err := m.call(ctx, opts, nil, "", &reply); err != nil {
+		return 0, err
+	}
+	return reply.MediaId, nil
+}
+
+// appendAll reads r in chunkSize segments, uploading each with the
+// APPEND command, without holding the whole file in memory.
+func (m *MediaService) appendAll(ctx context.Context, mediaId int64, r io.Reader) error {
+	buf := make([]byte, chunkSize)
+	for segment := 0; ; segment++ {
+		n, err := io.ReadFull(r, buf)
+		if n > 0 {
+			opts := NewOptionals()
+			opts.Add("command", "APPEND")
+			opts.Add("media_id", mediaId)
+			opts.Add("segment_index", segment)
+			if appendErr := m.call(ctx, opts, bytes.NewReader(buf[:n]), "media", nil); appendErr != nil {
+				return appendErr
+			}
+		}
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// finalize performs the FINALIZE command, which returns the final
+// media_id and, for media Twitter needs to transcode, a
+// processing_info block to poll.
+func (m *MediaService) finalize(ctx context.Context, mediaId int64) (*mediaReply, error) {
+	opts := NewOptionals()
+	opts.Add("command", "FINALIZE")
+	opts.Add("media_id", mediaId)
+	var reply mediaReply
+	if err := m.call(ctx, opts, nil, "", &reply); err != nil {
+		return nil, err
+	}
+	return &reply, nil
+}
+
+// awaitProcessing polls the STATUS command until Twitter reports the
+// media item has finished processing, returning the final
+// processing_info it observed. A failure surfaces as an error built
+// from the processing_info error block.
+func (m *MediaService) awaitProcessing(ctx context.Context, mediaId int64, info *ProcessingInfo) (*ProcessingInfo, error) {
+	for {
+		wait := info.CheckAfterSecs
+		if wait <= 0 {
+			wait = 1
+		}
+		select {
+		case <-time.After(time.Duration(wait) * time.Second):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+
+		opts := NewOptionals()
+		opts.Add("command", "STATUS")
+		opts.Add("media_id", mediaId)
+		var reply mediaReply
+		if err := m.callGET(ctx, opts, &reply); err != nil {
+			return nil, err
+		}
+		info = reply.ProcessingInfo
+		if info == nil {
+			return nil, nil
+		}
+		switch info.State {
+		case "succeeded":
+			return info, nil
+		case "failed":
+			if info.Error != nil {
+				return nil, fmt.Errorf("tweetlib: media processing failed: %s (%d)", info.Error.Message, info.Error.Code)
+			}
+			return nil, fmt.Errorf("tweetlib: media processing failed")
+		}
+	}
+}
+
+// call issues a POST against the media upload endpoint. When body is
+// non-nil it is sent as the named multipart field fieldName alongside
+// opts; otherwise opts alone are sent in the URL query string.
+func (m *MediaService) call(ctx context.Context, opts *Optionals, body io.Reader, fieldName string, resp interface{}) error {
+	if err := m.client.requireUserAuth("POST", "media/upload"); err != nil {
+		return err
+	}
+	if body == nil {
+		req, err := http.NewRequest("POST", mediaUploadURL+"?"+opts.Values.Encode(), nil)
+		if err != nil {
+			return err
+		}
+		return m.do(ctx, req, resp)
+	}
+
+	buf := bytes.NewBuffer(nil)
+	mp := multipart.NewWriter(buf)
+	for n, v := range opts.Values {
+		mp.WriteField(n, v[0])
+	}
+	part, err := mp.CreateFormFile(fieldName, fieldName)
+	if err != nil {
+		return err
+	}
+	if _, err = io.Copy(part, body); err != nil {
+		return err
+	}
+	header := fmt.Sprintf("multipart/form-data;boundary=%v", mp.Boundary())
+	mp.Close()
+
+	req, err := http.NewRequest("POST", mediaUploadURL, buf)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", header)
+	return m.do(ctx, req, resp)
+}
+
+// callGET issues a GET against the media upload endpoint, used for
+// the STATUS command.
+func (m *MediaService) callGET(ctx context.Context, opts *Optionals, resp interface{}) error {
+	req, err := http.NewRequest("GET", mediaUploadURL+"?"+opts.Values.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	return m.do(ctx, req, resp)
+}
+
+func (m *MediaService) do(ctx context.Context, req *http.Request, resp interface{}) error {
+	req = req.WithContext(ctx)
+	if err := m.client.applyAuth(req); err != nil {
+		return err
+	}
+	res, err := m.client.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer res.Body.Close()
+	if err = m.client.checkResponse(res); err != nil {
+		return err
+	}
+	if resp == nil {
+		ioutil.ReadAll(res.Body)
+		return nil
+	}
+	slurp, err := ioutil.ReadAll(res.Body)
+	if err != nil {
+		return err
+	}
+	if len(slurp) == 0 {
+		return nil
+	}
+	return json.Unmarshal(slurp, resp)
+}
@@ -0,0 +1,183 @@
+// tweetlib - A fully oauth-authenticated Go Twitter library
+//
+// Copyright 2011 The Tweetlib Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tweetlib
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func newTestMediaClient(handler http.HandlerFunc) (*Client, *MediaService, func()) {
+	srv := httptest.NewServer(handler)
+	oldURL := mediaUploadURL
+	mediaUploadURL = srv.URL
+	c := &Client{client: srv.Client()}
+	m := &MediaService{client: c}
+	return c, m, func() {
+		srv.Close()
+		mediaUploadURL = oldURL
+	}
+}
+
+func TestUploadRunsInitAppendFinalizeInOrder(t *testing.T) {
+	var commands []string
+	_, m, closeSrv := newTestMediaClient(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseMultipartForm(32 << 20)
+		command := r.FormValue("command")
+		if command == "" {
+			command = r.URL.Query().Get("command")
+		}
+		commands = append(commands, command)
+		switch command {
+		case "INIT":
+			w.Write([]byte(`{"media_id":42,"media_id_string":"42"}`))
+		case "APPEND":
+			w.WriteHeader(http.StatusNoContent)
+		case "FINALIZE":
+			w.Write([]byte(`{"media_id":42,"media_id_string":"42"}`))
+		}
+	})
+	defer closeSrv()
+
+	result, err := m.Upload(strings.NewReader("hello"), "image/jpeg", "tweet_image", 5)
+	if err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	if result.MediaId != 42 || result.MediaIdString != "42" {
+		t.Fatalf("got %+v, want MediaId=42", result)
+	}
+	want := []string{"INIT", "APPEND", "FINALIZE"}
+	if len(commands) != len(want) {
+		t.Fatalf("got commands %v, want %v", commands, want)
+	}
+	for i := range want {
+		if commands[i] != want[i] {
+			t.Fatalf("got commands %v, want %v", commands, want)
+		}
+	}
+}
+
+func TestAppendAllSplitsContentIntoMultipleChunks(t *testing.T) {
+	segments := make(map[string]int)
+	_, m, closeSrv := newTestMediaClient(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseMultipartForm(32 << 20)
+		switch r.FormValue("command") {
+		case "INIT":
+			w.Write([]byte(`{"media_id":7,"media_id_string":"7"}`))
+		case "APPEND":
+			segments[r.FormValue("segment_index")]++
+			w.WriteHeader(http.StatusNoContent)
+		case "FINALIZE":
+			w.Write([]byte(`{"media_id":7,"media_id_string":"7"}`))
+		}
+	})
+	defer closeSrv()
+
+	data := bytes.Repeat([]byte("x"), chunkSize+1)
+	if _, err := m.Upload(bytes.NewReader(data), "video/mp4", "tweet_video", int64(len(data))); err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	if len(segments) != 2 || segments["0"] != 1 || segments["1"] != 1 {
+		t.Fatalf("got segments %v, want segment_index 0 and 1 each uploaded once", segments)
+	}
+}
+
+func TestUploadPollsStatusUntilProcessingSucceeds(t *testing.T) {
+	statusCalls := 0
+	_, m, closeSrv := newTestMediaClient(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			statusCalls++
+			if statusCalls < 2 {
+				w.Write([]byte(`{"media_id":9,"media_id_string":"9","processing_info":{"state":"in_progress","check_after_secs":0}}`))
+				return
+			}
+			w.Write([]byte(`{"media_id":9,"media_id_string":"9","processing_info":{"state":"succeeded"}}`))
+			return
+		}
+		r.ParseMultipartForm(32 << 20)
+		switch r.FormValue("command") {
+		case "INIT":
+			w.Write([]byte(`{"media_id":9,"media_id_string":"9"}`))
+		case "APPEND":
+			w.WriteHeader(http.StatusNoContent)
+		case "FINALIZE":
+			w.Write([]byte(`{"media_id":9,"media_id_string":"9","processing_info":{"state":"pending","check_after_secs":0}}`))
+		}
+	})
+	defer closeSrv()
+
+	result, err := m.Upload(strings.NewReader("x"), "video/mp4", "tweet_video", 1)
+	if err != nil {
+		t.Fatalf("Upload: %v", err)
+	}
+	if result.ProcessingInfo == nil || result.ProcessingInfo.State != "succeeded" {
+		t.Fatalf("got %+v, want a succeeded ProcessingInfo", result.ProcessingInfo)
+	}
+	if statusCalls < 2 {
+		t.Fatalf("got %d STATUS polls, want at least 2", statusCalls)
+	}
+}
+
+func TestUploadSurfacesAProcessingFailure(t *testing.T) {
+	_, m, closeSrv := newTestMediaClient(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			w.Write([]byte(`{"media_id":9,"media_id_string":"9","processing_info":{"state":"failed","error":{"code":1,"name":"InvalidMedia","message":"unsupported file"}}}`))
+			return
+		}
+		r.ParseMultipartForm(32 << 20)
+		switch r.FormValue("command") {
+		case "INIT":
+			w.Write([]byte(`{"media_id":9,"media_id_string":"9"}`))
+		case "APPEND":
+			w.WriteHeader(http.StatusNoContent)
+		case "FINALIZE":
+			w.Write([]byte(`{"media_id":9,"media_id_string":"9","processing_info":{"state":"pending","check_after_secs":0}}`))
+		}
+	})
+	defer closeSrv()
+
+	if _, err := m.Upload(strings.NewReader("x"), "video/mp4", "tweet_video", 1); err == nil {
+		t.Fatal("expected an error when processing fails")
+	} else if !strings.Contains(err.Error(), "unsupported file") {
+		t.Fatalf("got %q, want it to mention the processing error", err)
+	}
+}
+
+func TestUploadContextCancellationStopsTheUpload(t *testing.T) {
+	_, m, closeSrv := newTestMediaClient(func(w http.ResponseWriter, r *http.Request) {
+		r.ParseMultipartForm(32 << 20)
+		switch r.FormValue("command") {
+		case "INIT":
+			w.Write([]byte(`{"media_id":9,"media_id_string":"9"}`))
+		case "APPEND":
+			w.WriteHeader(http.StatusNoContent)
+		case "FINALIZE":
+			w.Write([]byte(`{"media_id":9,"media_id_string":"9","processing_info":{"state":"pending","check_after_secs":3}}`))
+		}
+	})
+	defer closeSrv()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	_, err := m.UploadContext(ctx, strings.NewReader("x"), "video/mp4", "tweet_video", 1)
+	if err != context.DeadlineExceeded {
+		t.Fatalf("got %v, want context.DeadlineExceeded while awaiting processing", err)
+	}
+}
+
+func TestUploadRequiresUserAuth(t *testing.T) {
+	c := &Client{appAuth: NewAppAuth("key", "secret")}
+	m := &MediaService{client: c}
+	if _, err := m.Upload(strings.NewReader("x"), "image/jpeg", "tweet_image", 1); err == nil {
+		t.Fatal("expected Upload to reject an application-only auth Client")
+	}
+}
@@ -0,0 +1,266 @@
+// tweetlib - A fully oauth-authenticated Go Twitter library
+//
+// Copyright 2011 The Tweetlib Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tweetlib
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// RateLimitPolicy controls how a Client reacts when it knows (from a
+// previous response) that an endpoint's rate-limit window is
+// exhausted.
+type RateLimitPolicy int
+
+const (
+	// PolicyNone performs no throttling; calls are dispatched as
+	// usual and Twitter may answer with a 429. This is the default.
+	PolicyNone RateLimitPolicy = iota
+	// PolicyBlock sleeps until the rate-limit window resets before
+	// dispatching a call that would otherwise be rejected.
+	PolicyBlock
+	// PolicyError returns a RateLimitError immediately, without
+	// contacting Twitter, for a call that would otherwise be rejected.
+	PolicyError
+	// PolicyQueue throttles calls through a token bucket sized to the
+	// endpoint's remaining quota, spreading them out until the window
+	// resets instead of blocking in a single big sleep.
+	PolicyQueue
+)
+
+// RateLimitError is returned by CallJSON under PolicyError when the
+// tracked quota for an endpoint is already exhausted.
+type RateLimitError struct {
+	Endpoint  string
+	RateLimit RateLimit
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("tweetlib: %s is rate-limited until %s", e.Endpoint, e.RateLimit.Reset)
+}
+
+// rateLimitTracker remembers the most recently observed rate-limit
+// window for each endpoint family and, under PolicyQueue, a token
+// bucket that paces calls across that window.
+type rateLimitTracker struct {
+	mu      sync.Mutex
+	windows map[string]RateLimit
+	buckets map[string]*tokenBucket
+}
+
+// tokenBucket is a PolicyQueue queue for a single endpoint family: a
+// channel of tokens sized to the family's quota, and the reset time of
+// the window it was last sized from, so it can be refilled once that
+// window passes even if no response ever reports fresh quota.
+type tokenBucket struct {
+	tokens  chan struct{}
+	limit   int
+	resetAt time.Time
+}
+
+func newRateLimitTracker() *rateLimitTracker {
+	return &rateLimitTracker{
+		windows: make(map[string]RateLimit),
+		buckets: make(map[string]*tokenBucket),
+	}
+}
+
+// endpointFamily maps a specific endpoint (e.g.
+// "statuses/user_timeline") to the family Twitter groups it under for
+// rate-limiting purposes (e.g. "/statuses/user_timeline"). Twitter
+// groups almost all endpoints this way, so a leading slash is enough.
+func endpointFamily(endpoint string) string {
+	return "/" + endpoint
+}
+
+// seed records a rate-limit window learned outside of CallJSON, e.g.
+// from Client.Limits().
+func (t *rateLimitTracker) seed(family string, rl RateLimit) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.windows[family] = rl
+}
+
+// update records the rate-limit window observed on a response.
+func (t *rateLimitTracker) update(family string, rl RateLimit) {
+	if rl.Limit == 0 {
+		return
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.windows[family] = rl
+	if bucket, ok := t.buckets[family]; ok {
+		bucket.resetAt = rl.Reset
+		if rl.Remaining > 0 {
+			select {
+			case bucket.tokens <- struct{}{}:
+			default:
+			}
+		}
+	}
+}
+
+// window returns the most recently observed window for family, the
+// zero RateLimit if none has been observed yet.
+func (t *rateLimitTracker) window(family string) RateLimit {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.windows[family]
+}
+
+// exhausted reports the tracked window for family, if its quota has
+// been used up and the reset time has not yet passed.
+func (t *rateLimitTracker) exhausted(family string) (RateLimit, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	rl, ok := t.windows[family]
+	if !ok || rl.Remaining > 0 {
+		return RateLimit{}, false
+	}
+	if !rl.Reset.IsZero() && time.Now().After(rl.Reset) {
+		return RateLimit{}, false
+	}
+	return rl, true
+}
+
+// acquire blocks until a queue slot for family is available, sized to
+// the endpoint's last known quota, or until ctx is done. If the window
+// it was sized from passes before a token is released, acquire refills
+// the bucket itself rather than waiting forever for a response that,
+// with the quota exhausted, may never arrive.
+func (t *rateLimitTracker) acquire(ctx context.Context, family string, rl RateLimit) error {
+	for {
+		bucket := t.bucketFor(family, rl)
+
+		var resetC <-chan time.Time
+		if d := time.Until(bucket.resetAt); !bucket.resetAt.IsZero() && d > 0 {
+			resetC = time.After(d)
+		} else if !bucket.resetAt.IsZero() {
+			// The window has already passed; refill now instead of
+			// taking a full trip through the select below.
+			t.refill(family, bucket)
+			continue
+		}
+
+		select {
+		case <-bucket.tokens:
+			return nil
+		case <-resetC:
+			t.refill(family, bucket)
+			continue
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// bucketFor returns the token bucket for family, creating it or
+// resizing it to rl.Limit if Twitter has since reported a different
+// quota than the bucket was built with.
+func (t *rateLimitTracker) bucketFor(family string, rl RateLimit) *tokenBucket {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	size := rl.Limit
+	if size <= 0 {
+		size = 1
+	}
+	bucket, ok := t.buckets[family]
+	if !ok {
+		bucket = &tokenBucket{tokens: make(chan struct{}, size), limit: size, resetAt: rl.Reset}
+		for i := 0; i < size; i++ {
+			bucket.tokens <- struct{}{}
+		}
+		t.buckets[family] = bucket
+	} else if size != bucket.limit {
+		bucket.tokens = make(chan struct{}, size)
+		for i := 0; i < size; i++ {
+			bucket.tokens <- struct{}{}
+		}
+		bucket.limit = size
+		bucket.resetAt = rl.Reset
+	}
+	return bucket
+}
+
+// refill tops bucket back up to its full quota and clears resetAt, so
+// it won't be refilled again until a subsequent response (via update)
+// reports a new window to wait out.
+func (t *rateLimitTracker) refill(family string, bucket *tokenBucket) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for i := 0; i < bucket.limit; i++ {
+		select {
+		case bucket.tokens <- struct{}{}:
+		default:
+		}
+	}
+	bucket.resetAt = time.Time{}
+}
+
+// SetRateLimitPolicy turns on rate-limit awareness for the Client:
+// once a response has told it how much quota an endpoint has left,
+// subsequent calls to that endpoint are throttled according to
+// policy. The default, PolicyNone, performs no throttling at all.
+func (c *Client) SetRateLimitPolicy(policy RateLimitPolicy) {
+	c.rateLimitPolicy = policy
+	if policy != PolicyNone && c.rateLimiter == nil {
+		c.rateLimiter = newRateLimitTracker()
+	}
+}
+
+// SeedRateLimit primes the tracker for endpoint with a window learned
+// out of band, e.g. from a resource entry in the reply of Limits()
+// (GET application/rate_limit_status), so the very first call to that
+// endpoint can already be throttled instead of needing a round trip
+// first. It is a no-op unless a rate-limit policy has been set.
+func (c *Client) SeedRateLimit(endpoint string, rl RateLimit) {
+	if c.rateLimiter == nil {
+		return
+	}
+	c.rateLimiter.seed(endpointFamily(endpoint), rl)
+}
+
+// throttle is consulted by CallJSONContext before a request is
+// dispatched. Under PolicyBlock/PolicyQueue it can sleep for as long
+// as the tracked window takes to reset, so it honors ctx throughout
+// rather than only before or after the wait.
+func (c *Client) throttle(ctx context.Context, endpoint string) error {
+	if c.rateLimitPolicy == PolicyNone || c.rateLimiter == nil {
+		return nil
+	}
+	family := endpointFamily(endpoint)
+	switch c.rateLimitPolicy {
+	case PolicyQueue:
+		return c.rateLimiter.acquire(ctx, family, c.rateLimiter.window(family))
+	case PolicyBlock:
+		if rl, ok := c.rateLimiter.exhausted(family); ok {
+			if d := time.Until(rl.Reset); d > 0 {
+				select {
+				case <-time.After(d):
+				case <-ctx.Done():
+					return ctx.Err()
+				}
+			}
+		}
+	case PolicyError:
+		if rl, ok := c.rateLimiter.exhausted(family); ok {
+			return &RateLimitError{Endpoint: endpoint, RateLimit: rl}
+		}
+	}
+	return nil
+}
+
+// recordRateLimit updates the tracker from a response's headers. It
+// is a no-op when rate-limit awareness hasn't been turned on.
+func (c *Client) recordRateLimit(endpoint string, res RateLimit) {
+	if c.rateLimiter == nil {
+		return
+	}
+	c.rateLimiter.update(endpointFamily(endpoint), res)
+}
@@ -0,0 +1,137 @@
+// tweetlib - A fully oauth-authenticated Go Twitter library
+//
+// Copyright 2011 The Tweetlib Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tweetlib
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestEndpointFamily(t *testing.T) {
+	if got := endpointFamily("statuses/user_timeline"); got != "/statuses/user_timeline" {
+		t.Fatalf("got %q, want %q", got, "/statuses/user_timeline")
+	}
+}
+
+func TestWindowReturnsZeroValueUntilSeeded(t *testing.T) {
+	tr := newRateLimitTracker()
+	if rl := tr.window("/statuses/user_timeline"); rl.Limit != 0 {
+		t.Fatalf("got %+v, want the zero RateLimit", rl)
+	}
+	seeded := RateLimit{Limit: 180, Remaining: 180, Reset: time.Now().Add(time.Hour)}
+	tr.seed("/statuses/user_timeline", seeded)
+	if got := tr.window("/statuses/user_timeline"); got != seeded {
+		t.Fatalf("got %+v, want %+v", got, seeded)
+	}
+}
+
+func TestUpdateIgnoresAZeroLimit(t *testing.T) {
+	tr := newRateLimitTracker()
+	tr.seed("/x", RateLimit{Limit: 15, Remaining: 1})
+	tr.update("/x", RateLimit{})
+	if got := tr.window("/x"); got.Limit != 15 {
+		t.Fatalf("update with Limit: 0 should be a no-op, got %+v", got)
+	}
+}
+
+func TestExhaustedReportsUsedUpQuota(t *testing.T) {
+	tr := newRateLimitTracker()
+	family := "/x"
+
+	if _, ok := tr.exhausted(family); ok {
+		t.Fatal("nothing tracked yet; should not be exhausted")
+	}
+
+	tr.update(family, RateLimit{Limit: 15, Remaining: 1, Reset: time.Now().Add(time.Hour)})
+	if _, ok := tr.exhausted(family); ok {
+		t.Fatal("quota remains; should not be exhausted")
+	}
+
+	tr.update(family, RateLimit{Limit: 15, Remaining: 0, Reset: time.Now().Add(time.Hour)})
+	if rl, ok := tr.exhausted(family); !ok || rl.Remaining != 0 {
+		t.Fatalf("got ok=%v rl=%+v, want exhausted with Remaining=0", ok, rl)
+	}
+
+	tr.update(family, RateLimit{Limit: 15, Remaining: 0, Reset: time.Now().Add(-time.Second)})
+	if _, ok := tr.exhausted(family); ok {
+		t.Fatal("reset time has passed; should no longer be exhausted")
+	}
+}
+
+func TestBucketForResizesWhenLimitChanges(t *testing.T) {
+	tr := newRateLimitTracker()
+	family := "/x"
+
+	b := tr.bucketFor(family, RateLimit{Limit: 2})
+	if b.limit != 2 || len(b.tokens) != 2 {
+		t.Fatalf("got limit=%d len=%d, want limit=2 len=2", b.limit, len(b.tokens))
+	}
+
+	b = tr.bucketFor(family, RateLimit{Limit: 5})
+	if b.limit != 5 || len(b.tokens) != 5 {
+		t.Fatalf("got limit=%d len=%d, want limit=5 len=5 after resize", b.limit, len(b.tokens))
+	}
+
+	same := tr.bucketFor(family, RateLimit{Limit: 5})
+	if same != b {
+		t.Fatal("bucketFor should return the existing bucket when the limit is unchanged")
+	}
+}
+
+func TestAcquireReturnsImmediatelyWhenATokenIsAvailable(t *testing.T) {
+	tr := newRateLimitTracker()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := tr.acquire(ctx, "/x", RateLimit{Limit: 1}); err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+}
+
+func TestAcquireRefillsOnceTheTrackedWindowPasses(t *testing.T) {
+	tr := newRateLimitTracker()
+	family := "/x"
+
+	// Sizes the bucket to 1 token and immediately drains it.
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	rl := RateLimit{Limit: 1, Remaining: 0, Reset: time.Now().Add(20 * time.Millisecond)}
+	if err := tr.acquire(ctx, family, rl); err != nil {
+		t.Fatalf("first acquire: %v", err)
+	}
+
+	// The bucket is now empty and its resetAt is in the near future; a
+	// second acquire should block past the window, refill, and succeed,
+	// rather than hang forever waiting for a response that never comes.
+	done := make(chan error, 1)
+	go func() { done <- tr.acquire(ctx, family, rl) }()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("second acquire: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("acquire did not refill after the tracked window passed")
+	}
+}
+
+func TestAcquireHonorsContextCancellation(t *testing.T) {
+	tr := newRateLimitTracker()
+	family := "/x"
+	rl := RateLimit{Limit: 1, Remaining: 0, Reset: time.Now().Add(time.Hour)}
+	// Drain the single token so the next acquire has nothing to take and
+	// no near-term reset to wait out.
+	if err := tr.acquire(context.Background(), family, rl); err != nil {
+		t.Fatalf("draining acquire: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := tr.acquire(ctx, family, rl); err == nil {
+		t.Fatal("expected acquire to return ctx.Err() on an already-canceled context")
+	}
+}
@@ -0,0 +1,372 @@
+// tweetlib - A fully oauth-authenticated Go Twitter library
+//
+// Copyright 2011 The Tweetlib Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tweetlib
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// Base URL for the public streaming endpoints (filter, sample, firehose)
+	streamURL = "https://stream.twitter.com/1.1"
+	// Base URL for user streams
+	userStreamURL = "https://userstream.twitter.com/1.1"
+)
+
+// StreamService gives access to Twitter's streaming API.
+// See https://dev.twitter.com/docs/streaming-apis
+type StreamService struct {
+	client *Client
+}
+
+// Stream represents a single, long-lived connection to one of
+// Twitter's streaming endpoints. Decoded messages are delivered on
+// Messages as they arrive on the wire; connection level problems are
+// reported on Errors. The Stream reconnects on its own following
+// Twitter's recommended backoff policy, so callers only need to range
+// over Messages and call Stop when they are done.
+type Stream struct {
+	// Messages yields *Tweet, *Message, *StreamDeleteNotice,
+	// *StreamLimitNotice, *StreamDisconnect or *StallWarning values,
+	// depending on what Twitter sends down the wire.
+	Messages chan interface{}
+	// Errors reports connection-level errors. A Stream keeps trying
+	// to reconnect after an error unless Stop has been called.
+	Errors chan error
+
+	client   *Client
+	method   string
+	endpoint string
+	opts     *Optionals
+
+	stop   chan struct{}
+	once   sync.Once
+	ctx    context.Context
+	cancel context.CancelFunc
+}
+
+// StreamDeleteNotice is sent when a tweet the client previously saw
+// has since been deleted and should be removed from any stored or
+// displayed copies.
+type StreamDeleteNotice struct {
+	Id     int64 `json:"id"`
+	UserId int64 `json:"user_id"`
+}
+
+// StreamLimitNotice is sent when Twitter has had to discard messages
+// because the stream fell behind. Track indicates how many matching
+// messages were dropped since the stream started.
+type StreamLimitNotice struct {
+	Track int64 `json:"track"`
+}
+
+// StreamDisconnect is sent right before Twitter closes a stream and
+// explains why the disconnect happened.
+type StreamDisconnect struct {
+	Code     int    `json:"code"`
+	StreamName string `json:"stream_name"`
+	Reason   string `json:"reason"`
+}
+
+// StallWarning is sent when a client is in danger of being
+// disconnected for reading too slowly.
+type StallWarning struct {
+	Code        string `json:"code"`
+	Message     string `json:"message"`
+	PercentFull int    `json:"percent_full"`
+}
+
+// rawStreamMessage is used to sniff the top-level key of an incoming
+// line before deciding which concrete type to unmarshal it into.
+type rawStreamMessage struct {
+	Delete     *struct{ Status StreamDeleteNotice `json:"status"` } `json:"delete"`
+	Limit      *StreamLimitNotice  `json:"limit"`
+	Disconnect *StreamDisconnect   `json:"disconnect"`
+	Warning    *StallWarning       `json:"warning"`
+	DirectMessage *Message         `json:"direct_message"`
+}
+
+// Filter streams tweets matching the given track, follow and locations
+// parameters. opts follows the same conventions as the rest of the
+// library: use opts.Add("track", "twitter,api") etc.
+// See https://dev.twitter.com/docs/api/1.1/post/statuses/filter
+func (s *StreamService) Filter(opts *Optionals) (*Stream, error) {
+	return s.open("POST", "statuses/filter", opts)
+}
+
+// Sample returns a small random sample of all public statuses.
+// See https://dev.twitter.com/docs/api/1.1/get/statuses/sample
+func (s *StreamService) Sample(opts *Optionals) (*Stream, error) {
+	return s.open("GET", "statuses/sample", opts)
+}
+
+// User streams messages belonging to the authenticating user: their
+// tweets, mentions, direct messages and more.
+// See https://dev.twitter.com/docs/api/1.1/get/user
+func (s *StreamService) User(opts *Optionals) (*Stream, error) {
+	return s.open("GET", "user", opts)
+}
+
+// open starts the reconnect loop for a stream and returns immediately;
+// the loop itself runs in its own goroutine until Stop is called.
+func (s *StreamService) open(method, endpoint string, opts *Optionals) (*Stream, error) {
+	if opts == nil {
+		opts = NewOptionals()
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	st := &Stream{
+		Messages: make(chan interface{}),
+		Errors:   make(chan error, 1),
+		client:   s.client,
+		method:   method,
+		endpoint: endpoint,
+		opts:     opts,
+		stop:     make(chan struct{}),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+	go st.run()
+	return st, nil
+}
+
+// Stop closes the stream's connection, if any - including one that's
+// still dialing or blocked reading an idle connection - and releases
+// its goroutine. It is safe to call Stop more than once.
+func (st *Stream) Stop() {
+	st.once.Do(func() {
+		close(st.stop)
+		st.cancel()
+	})
+}
+
+// run drives the reconnect loop described at
+// https://dev.twitter.com/docs/streaming-apis/connecting, applying
+// the backoff policy appropriate to whatever failure caused the
+// previous connection to drop.
+func (st *Stream) run() {
+	var networkBackoff = 250 * time.Millisecond
+	var httpBackoff = 5 * time.Second
+	var rateLimitBackoff = time.Minute
+
+	for {
+		connectedAt := time.Now()
+		res, err := st.connect()
+		if err != nil {
+			st.reportError(err)
+			if !st.wait(networkBackoff) {
+				return
+			}
+			networkBackoff = nextNetworkBackoff(networkBackoff)
+			continue
+		}
+
+		switch {
+		case res.StatusCode == http.StatusOK:
+			err = st.consume(res)
+			if time.Since(connectedAt) >= time.Minute {
+				// Only a connection that stayed open long enough to
+				// be considered healthy resets the backoffs; a
+				// connection that drops immediately should keep
+				// backing off instead of hammering the endpoint.
+				networkBackoff = 250 * time.Millisecond
+				httpBackoff = 5 * time.Second
+				rateLimitBackoff = time.Minute
+			}
+		case res.StatusCode == http.StatusTooManyRequests || res.StatusCode == 420:
+			res.Body.Close()
+			st.reportError(fmt.Errorf("tweetlib: stream connection rate-limited with status %d", res.StatusCode))
+			if !st.wait(rateLimitBackoff) {
+				return
+			}
+			rateLimitBackoff = nextRateLimitBackoff(rateLimitBackoff)
+			continue
+		case res.StatusCode >= 500:
+			res.Body.Close()
+			st.reportError(fmt.Errorf("tweetlib: stream connection failed with status %d", res.StatusCode))
+			if !st.wait(httpBackoff) {
+				return
+			}
+			httpBackoff = nextHTTPBackoff(httpBackoff)
+			continue
+		default:
+			res.Body.Close()
+			st.reportError(fmt.Errorf("tweetlib: stream connection failed with status %d", res.StatusCode))
+			if !st.wait(httpBackoff) {
+				return
+			}
+			continue
+		}
+
+		if err != nil {
+			st.reportError(err)
+			if !st.wait(networkBackoff) {
+				return
+			}
+		}
+
+		select {
+		case <-st.stop:
+			return
+		default:
+		}
+	}
+}
+
+// nextNetworkBackoff grows a network-error backoff linearly (per
+// Twitter's reconnect guidance), capped at 16s.
+func nextNetworkBackoff(d time.Duration) time.Duration {
+	d += 250 * time.Millisecond
+	if d > 16*time.Second {
+		d = 16 * time.Second
+	}
+	return d
+}
+
+// nextHTTPBackoff grows an HTTP 5xx backoff exponentially, capped at
+// 320s.
+func nextHTTPBackoff(d time.Duration) time.Duration {
+	d *= 2
+	if d > 320*time.Second {
+		d = 320 * time.Second
+	}
+	return d
+}
+
+// nextRateLimitBackoff grows a 420/429 backoff exponentially, capped
+// at 16 minutes.
+func nextRateLimitBackoff(d time.Duration) time.Duration {
+	d *= 2
+	if d > 16*time.Minute {
+		d = 16 * time.Minute
+	}
+	return d
+}
+
+// connect issues the HTTP request for the stream using the client's
+// regular, OAuth-signed transport. Streaming endpoints always require
+// a signed, user-context request, even the GET ones (Sample, and
+// especially the per-user /user stream) - so, unlike CallJSONContext,
+// bearer (app-only) auth is rejected outright rather than attached.
+func (st *Stream) connect() (*http.Response, error) {
+	if st.client.appAuth != nil {
+		return nil, fmt.Errorf("tweetlib: streaming endpoints require user authentication; this Client uses application-only auth")
+	}
+	base := streamURL
+	if st.endpoint == "user" {
+		base = userStreamURL
+	}
+	endpoint := fmt.Sprintf("%s/%s.json", base, st.endpoint)
+	var req *http.Request
+	var err error
+	if st.method == "POST" {
+		req, err = http.NewRequest("POST", endpoint, strings.NewReader(st.opts.Values.Encode()))
+		if err == nil {
+			req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+		}
+	} else {
+		req, err = http.NewRequest("GET", endpoint+"?"+st.opts.Values.Encode(), nil)
+	}
+	if err != nil {
+		return nil, err
+	}
+	req = req.WithContext(st.ctx)
+	return st.client.client.Do(req)
+}
+
+// consume reads the body of an open connection line by line, dispatching
+// each decoded message until the body is closed, Stop is called or a
+// read error occurs.
+func (st *Stream) consume(res *http.Response) error {
+	defer res.Body.Close()
+	r := bufio.NewReader(res.Body)
+	for {
+		select {
+		case <-st.stop:
+			return nil
+		default:
+		}
+		line, err := r.ReadBytes('\n')
+		line = []byte(strings.TrimSpace(string(line)))
+		if len(line) > 0 {
+			if !st.dispatch(line) {
+				return nil
+			}
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
+
+// dispatch decodes a single line of the stream and sends the
+// appropriate typed value on Messages. It reports whether the caller
+// should keep reading; it returns false as soon as Stop is called,
+// even if that means dropping the decoded message on the floor.
+func (st *Stream) dispatch(line []byte) bool {
+	var raw rawStreamMessage
+	if err := json.Unmarshal(line, &raw); err == nil {
+		switch {
+		case raw.Delete != nil:
+			notice := raw.Delete.Status
+			return st.send(&notice)
+		case raw.Limit != nil:
+			return st.send(raw.Limit)
+		case raw.Disconnect != nil:
+			return st.send(raw.Disconnect)
+		case raw.Warning != nil:
+			return st.send(raw.Warning)
+		case raw.DirectMessage != nil:
+			return st.send(raw.DirectMessage)
+		}
+	}
+	var tweet Tweet
+	if err := json.Unmarshal(line, &tweet); err != nil {
+		st.reportError(err)
+		return true
+	}
+	return st.send(&tweet)
+}
+
+// send delivers msg on Messages, unblocking instead of leaking the
+// goroutine if Stop is called while no one is reading Messages.
+func (st *Stream) send(msg interface{}) bool {
+	select {
+	case st.Messages <- msg:
+		return true
+	case <-st.stop:
+		return false
+	}
+}
+
+func (st *Stream) reportError(err error) {
+	select {
+	case st.Errors <- err:
+	default:
+		// Don't block the reconnect loop if nobody is reading Errors.
+	}
+}
+
+// wait blocks for d plus a random jitter of up to a quarter of d,
+// returning false if Stop was called in the
+// meantime so callers can unwind immediately instead of reconnecting.
+func (st *Stream) wait(d time.Duration) bool {
+	jitter := time.Duration(rand.Int63n(int64(d) / 4 + 1))
+	select {
+	case <-time.After(d + jitter):
+		return true
+	case <-st.stop:
+		return false
+	}
+}
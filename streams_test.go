@@ -0,0 +1,159 @@
+// tweetlib - A fully oauth-authenticated Go Twitter library
+//
+// Copyright 2011 The Tweetlib Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tweetlib
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestNextNetworkBackoffIsLinearAndCapped(t *testing.T) {
+	d := 250 * time.Millisecond
+	for i := 0; i < 3; i++ {
+		d = nextNetworkBackoff(d)
+	}
+	if want := time.Second; d != want {
+		t.Fatalf("after 3 steps: got %s, want %s", d, want)
+	}
+	for i := 0; i < 100; i++ {
+		d = nextNetworkBackoff(d)
+	}
+	if want := 16 * time.Second; d != want {
+		t.Fatalf("capped backoff: got %s, want %s", d, want)
+	}
+}
+
+func TestNextHTTPBackoffIsExponentialAndCapped(t *testing.T) {
+	d := 5 * time.Second
+	d = nextHTTPBackoff(d)
+	if want := 10 * time.Second; d != want {
+		t.Fatalf("after 1 step: got %s, want %s", d, want)
+	}
+	for i := 0; i < 100; i++ {
+		d = nextHTTPBackoff(d)
+	}
+	if want := 320 * time.Second; d != want {
+		t.Fatalf("capped backoff: got %s, want %s", d, want)
+	}
+}
+
+func TestNextRateLimitBackoffIsExponentialAndCapped(t *testing.T) {
+	d := time.Minute
+	d = nextRateLimitBackoff(d)
+	if want := 2 * time.Minute; d != want {
+		t.Fatalf("after 1 step: got %s, want %s", d, want)
+	}
+	for i := 0; i < 100; i++ {
+		d = nextRateLimitBackoff(d)
+	}
+	if want := 16 * time.Minute; d != want {
+		t.Fatalf("capped backoff: got %s, want %s", d, want)
+	}
+}
+
+func newTestStream() *Stream {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Stream{
+		Messages: make(chan interface{}),
+		Errors:   make(chan error, 1),
+		stop:     make(chan struct{}),
+		ctx:      ctx,
+		cancel:   cancel,
+	}
+}
+
+func TestDispatchDecodesEachMessageType(t *testing.T) {
+	cases := []struct {
+		name string
+		line string
+		want func(interface{}) bool
+	}{
+		{"tweet", `{"id":42,"text":"hi"}`, func(v interface{}) bool {
+			tw, ok := v.(*Tweet)
+			return ok && tw.Id == 42
+		}},
+		{"delete", `{"delete":{"status":{"id":7,"user_id":9}}}`, func(v interface{}) bool {
+			n, ok := v.(*StreamDeleteNotice)
+			return ok && n.Id == 7 && n.UserId == 9
+		}},
+		{"limit", `{"limit":{"track":3}}`, func(v interface{}) bool {
+			n, ok := v.(*StreamLimitNotice)
+			return ok && n.Track == 3
+		}},
+		{"disconnect", `{"disconnect":{"code":1,"stream_name":"s","reason":"r"}}`, func(v interface{}) bool {
+			n, ok := v.(*StreamDisconnect)
+			return ok && n.Code == 1 && n.Reason == "r"
+		}},
+		{"warning", `{"warning":{"code":"FALLING_BEHIND","message":"m","percent_full":90}}`, func(v interface{}) bool {
+			n, ok := v.(*StallWarning)
+			return ok && n.PercentFull == 90
+		}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			st := newTestStream()
+			done := make(chan interface{}, 1)
+			go func() { done <- st.dispatch([]byte(tc.line)) }()
+			select {
+			case msg := <-st.Messages:
+				if !tc.want(msg) {
+					t.Fatalf("unexpected message: %#v", msg)
+				}
+			case <-time.After(time.Second):
+				t.Fatal("timed out waiting for dispatched message")
+			}
+		})
+	}
+}
+
+func TestStopUnblocksPendingSend(t *testing.T) {
+	st := newTestStream()
+	var wg sync.WaitGroup
+	wg.Add(1)
+	sent := make(chan bool, 1)
+	go func() {
+		defer wg.Done()
+		sent <- st.send(&Tweet{Id: 1})
+	}()
+
+	// Nobody ever reads st.Messages; without Stop this goroutine would
+	// block forever.
+	st.Stop()
+
+	select {
+	case ok := <-sent:
+		if ok {
+			t.Fatal("send reported success despite Stop being called first")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("send did not unblock after Stop")
+	}
+	wg.Wait()
+}
+
+func TestStopCancelsContext(t *testing.T) {
+	st := newTestStream()
+	st.Stop()
+	select {
+	case <-st.ctx.Done():
+	default:
+		t.Fatal("Stop did not cancel the stream's context")
+	}
+}
+
+func TestConnectRejectsAppAuthEvenForGETEndpoints(t *testing.T) {
+	st := newTestStream()
+	st.client = &Client{appAuth: NewAppAuth("key", "secret")}
+	st.method = "GET"
+	st.endpoint = "user"
+
+	if _, err := st.connect(); err == nil {
+		t.Fatal("expected an error connecting a GET stream endpoint with application-only auth")
+	}
+}
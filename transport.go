@@ -0,0 +1,124 @@
+// tweetlib - A fully oauth-authenticated Go Twitter library
+//
+// Copyright 2011 The Tweetlib Authors.  All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file.
+
+package tweetlib
+
+import (
+	"context"
+	"math/rand"
+	"net/http"
+	"time"
+)
+
+// Logger is the interface tweetlib uses for its own diagnostic
+// output. It is satisfied by *log.Logger. The default Client logs
+// nothing; pass WithLogger to NewWithOptions to see request URLs and
+// the response bodies of failed requests.
+type Logger interface {
+	Printf(format string, v ...interface{})
+}
+
+// noopLogger discards everything; it is the default for a Client that
+// hasn't been given a Logger.
+type noopLogger struct{}
+
+func (noopLogger) Printf(format string, v ...interface{}) {}
+
+// RetryPolicy controls whether CallJSONContext retries a failed
+// request and how long it waits between attempts.
+type RetryPolicy struct {
+	// MaxRetries is the number of extra attempts made after the
+	// first one fails. 0 disables retries.
+	MaxRetries int
+	// Backoff is the base delay before the first retry; it doubles
+	// on each subsequent attempt and is jittered by up to 50%.
+	Backoff time.Duration
+}
+
+// shouldRetry reports whether err/statusCode describe a failure worth
+// retrying: network errors and 5xx responses, but never a successful
+// response or a client error (4xx) since retrying those can't help.
+func (p RetryPolicy) shouldRetry(statusCode int, err error) bool {
+	if p.MaxRetries <= 0 {
+		return false
+	}
+	if err != nil {
+		return true
+	}
+	return statusCode >= 500
+}
+
+// wait sleeps out the backoff for attempt, returning ctx.Err() early if
+// ctx is done before the sleep would otherwise finish.
+func (p RetryPolicy) wait(ctx context.Context, attempt int) error {
+	d := p.Backoff << uint(attempt)
+	d += time.Duration(rand.Int63n(int64(d)/2 + 1))
+	select {
+	case <-time.After(d):
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// ClientOption configures a Client constructed with NewWithOptions.
+type ClientOption func(*Client)
+
+// WithHTTPClient overrides the *http.Client used to send requests.
+// It's most useful together with WithBaseURL to point a Client at a
+// mock server or a recording proxy in tests.
+func WithHTTPClient(hc *http.Client) ClientOption {
+	return func(c *Client) { c.client = hc }
+}
+
+// WithBaseURL overrides the API's base URL (default apiURL). Useful
+// for testing against a local mock of the Twitter API.
+func WithBaseURL(url string) ClientOption {
+	return func(c *Client) { c.baseURL = url }
+}
+
+// WithUserAgent sets the User-Agent header sent with every request.
+func WithUserAgent(ua string) ClientOption {
+	return func(c *Client) { c.userAgent = ua }
+}
+
+// WithLogger routes tweetlib's debug output (request URLs, response
+// bodies on error) through logger instead of discarding it.
+func WithLogger(logger Logger) ClientOption {
+	return func(c *Client) { c.logger = logger }
+}
+
+// WithRetry enables automatic retries of failed requests (network
+// errors and 5xx responses) according to policy.
+func WithRetry(policy RetryPolicy) ClientOption {
+	return func(c *Client) { c.retry = policy }
+}
+
+// WithRequestHook registers a function called with every outgoing
+// request just before it is sent, e.g. for logging or metrics.
+func WithRequestHook(hook func(*http.Request)) ClientOption {
+	return func(c *Client) { c.requestHook = hook }
+}
+
+// WithResponseHook registers a function called with every response
+// (and/or error) a request produces, e.g. for logging or metrics.
+func WithResponseHook(hook func(*http.Response, error)) ClientOption {
+	return func(c *Client) { c.responseHook = hook }
+}
+
+// NewWithOptions creates a new Client from transport the same way New
+// does, but applies opts afterwards, allowing callers to override the
+// HTTP client, base URL, logging and retry behavior.
+func NewWithOptions(transport *Transport, opts ...ClientOption) (*Client, error) {
+	c, err := New(transport)
+	if err != nil {
+		return nil, err
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c, nil
+}
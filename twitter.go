@@ -8,12 +8,12 @@ package tweetlib
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
 	"encoding/json"
 	"errors"
 	"fmt"
 	"io/ioutil"
-	"mime/multipart"
 	"net/http"
 	"reflect"
 )
@@ -51,13 +51,14 @@ func (ter *twitterErrorReply) String() string {
 	return buf.String()
 }
 
-// Checks whether the response is an error
-func checkResponse(res *http.Response) (err error) {
+// checkResponse checks whether res represents an error, logging the
+// raw body through c's Logger when it does.
+func (c *Client) checkResponse(res *http.Response) (err error) {
 	if res.StatusCode >= 200 && res.StatusCode <= 299 {
 		return nil
 	}
 	slurp, err := ioutil.ReadAll(res.Body)
-	fmt.Printf("%s\n", slurp)
+	c.effectiveLogger().Printf("%s", slurp)
 	if err != nil {
 		return err
 	}
@@ -65,7 +66,11 @@ func checkResponse(res *http.Response) (err error) {
 	if err = json.Unmarshal(slurp, &jerr); err != nil {
 		return
 	}
-	return errors.New(jerr.String())
+	return &APIError{
+		StatusCode: res.StatusCode,
+		Errors:     jerr.Errors,
+		RateLimit:  parseRateLimit(res),
+	}
 }
 
 // Creates a new twitter client
@@ -74,6 +79,8 @@ func New(transport *Transport) (*Client, error) {
 		return nil, errors.New("client is nil")
 	}
 	c := &Client{client: transport.Client()}
+	c.Streams = &StreamService{client: c}
+	c.Media = &MediaService{client: c}
 	return c, nil
 }
 
@@ -81,6 +88,76 @@ func New(transport *Transport) (*Client, error) {
 // API services
 type Client struct {
 	client *http.Client
+
+	// Streams gives access to Twitter's streaming endpoints, e.g.
+	// client.Streams.Filter(opts).
+	Streams *StreamService
+
+	// Media gives access to Twitter's chunked media upload endpoint,
+	// e.g. client.Media.Upload(r, "image/jpeg", "tweet_image", size).
+	Media *MediaService
+
+	// appAuth is set when the Client was created with NewAppClient and
+	// authenticates using application-only (bearer token) auth instead
+	// of user OAuth.
+	appAuth *AppAuth
+
+	// rateLimitPolicy and rateLimiter implement the opt-in throttling
+	// enabled by SetRateLimitPolicy.
+	rateLimitPolicy RateLimitPolicy
+	rateLimiter     *rateLimitTracker
+
+	// baseURL, userAgent, logger, retry and the request/response hooks
+	// are configured via NewWithOptions; a Client built with New uses
+	// their zero-value defaults (apiURL, no User-Agent override, no
+	// logging, no retries, no hooks).
+	baseURL      string
+	userAgent    string
+	logger       Logger
+	retry        RetryPolicy
+	requestHook  func(*http.Request)
+	responseHook func(*http.Response, error)
+}
+
+// effectiveBaseURL returns c.baseURL, falling back to apiURL for a
+// Client that didn't go through NewWithOptions(WithBaseURL(...)).
+func (c *Client) effectiveBaseURL() string {
+	if c.baseURL != "" {
+		return c.baseURL
+	}
+	return apiURL
+}
+
+// effectiveLogger returns c.logger, falling back to a logger that
+// discards everything.
+func (c *Client) effectiveLogger() Logger {
+	if c.logger != nil {
+		return c.logger
+	}
+	return noopLogger{}
+}
+
+// requireUserAuth fails fast, before any request is sent, when c uses
+// application-only auth and endpoint needs a user context (every POST
+// does; app-auth only grants read access). Media uses this too, so an
+// app-auth Client can't silently fire off an unauthenticated request
+// where a clear error is expected instead. Streams does not use it: it
+// rejects app-auth on its own, for GET stream endpoints as well.
+func (c *Client) requireUserAuth(method, endpoint string) error {
+	if c.appAuth != nil && method != "GET" {
+		return fmt.Errorf("tweetlib: %s requires user authentication; this Client uses application-only auth", endpoint)
+	}
+	return nil
+}
+
+// applyAuth attaches c's application-only bearer token to req, if c was
+// built with NewAppClient. It is a no-op for a regular, OAuth-signed
+// Client, which authenticates via its *http.Client's Transport instead.
+func (c *Client) applyAuth(req *http.Request) error {
+	if c.appAuth == nil {
+		return nil
+	}
+	return c.setBearerAuth(req)
 }
 
 // Performs an arbitrary API call and returns the response JSON if successful.
@@ -99,32 +176,98 @@ type Client struct {
 //
 //   tweet, err := client.UpdateStatus("Hello, world", nil)
 func (c *Client) CallJSON(method, endpoint string, opts *Optionals) (rawJSON []byte, err error) {
+	return c.CallJSONContext(context.Background(), method, endpoint, opts)
+}
+
+// CallJSONContext is CallJSON with a context.Context that governs the
+// whole call, including any retries: once ctx is done, the call
+// returns ctx.Err() instead of starting another attempt.
+func (c *Client) CallJSONContext(ctx context.Context, method, endpoint string, opts *Optionals) (rawJSON []byte, err error) {
 	if method != "GET" && method != "POST" {
 		err = fmt.Errorf("Invalid method '%s'. Must be either GET or POST.", method)
 		return
 	}
+	if err = c.requireUserAuth(method, endpoint); err != nil {
+		return
+	}
+	if err = c.throttle(ctx, endpoint); err != nil {
+		return
+	}
 	if opts == nil {
 		opts = NewOptionals()
 	}
-	endpoint = fmt.Sprintf("%s/%s.json?%s", apiURL, endpoint, opts.Values.Encode())
-	fmt.Println(endpoint)
-	var req *http.Request
-	if method == "POST" {
-		body := bytes.NewBuffer([]byte(opts.Values.Encode()))
-		req, _ = http.NewRequest(method, endpoint, body)
-		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
-	} else {
-		req, _ = http.NewRequest(method, endpoint, nil)
+	family := endpoint
+	endpointURL := fmt.Sprintf("%s/%s.json?%s", c.effectiveBaseURL(), endpoint, opts.Values.Encode())
+	c.effectiveLogger().Printf("tweetlib: %s %s", method, endpointURL)
+
+	for attempt := 0; ; attempt++ {
+		var req *http.Request
+		if method == "POST" {
+			body := bytes.NewBuffer([]byte(opts.Values.Encode()))
+			req, err = http.NewRequest(method, endpointURL, body)
+			if err == nil {
+				req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+			}
+		} else {
+			req, err = http.NewRequest(method, endpointURL, nil)
+		}
+		if err != nil {
+			return
+		}
+		req = req.WithContext(ctx)
+		if c.userAgent != "" {
+			req.Header.Set("User-Agent", c.userAgent)
+		}
+		if err = c.applyAuth(req); err != nil {
+			return
+		}
+
+		rawJSON, err = c.do(req, family)
+		if err == nil {
+			return
+		}
+		statusCode := 0
+		if apiErr, ok := err.(*APIError); ok {
+			statusCode = apiErr.StatusCode
+		}
+		if !c.retry.shouldRetry(statusCode, err) || attempt >= c.retry.MaxRetries {
+			return
+		}
+		if waitErr := c.retry.wait(ctx, attempt); waitErr != nil {
+			return nil, waitErr
+		}
+	}
+}
+
+// do sends a single request, running the configured hooks and
+// applying application-only auth's one-shot bearer retry.
+func (c *Client) do(req *http.Request, family string) (rawJSON []byte, err error) {
+	if c.requestHook != nil {
+		c.requestHook(req)
 	}
 	res, err := c.client.Do(req)
+	if c.responseHook != nil {
+		c.responseHook(res, err)
+	}
 	if err != nil {
-		return
+		return nil, err
 	}
-	if err = checkResponse(res); err != nil {
-		return
+	c.recordRateLimit(family, parseRateLimit(res))
+	if c.appAuth != nil && res.StatusCode == http.StatusUnauthorized {
+		// The bearer token may have been invalidated out from under us;
+		// fetch a new one and retry exactly once.
+		c.appAuth.InvalidateToken()
+		if err = c.setBearerAuth(req); err != nil {
+			return nil, err
+		}
+		if res, err = c.client.Do(req); err != nil {
+			return nil, err
+		}
 	}
-	rawJSON, err = ioutil.ReadAll(res.Body)
-	return
+	if err = c.checkResponse(res); err != nil {
+		return nil, err
+	}
+	return ioutil.ReadAll(res.Body)
 }
 
 // Performs an arbitrary API call and tries to unmarshal the result into
@@ -142,7 +285,12 @@ func (c *Client) CallJSON(method, endpoint string, opts *Optionals) (rawJSON []b
 //
 //     tweet, err := client.UpdateStatus("Hello, world", nil)
 func (c *Client) Call(method, endpoint string, opts *Optionals, resp interface{}) (err error) {
-	rawJSON, err := c.CallJSON(method, endpoint, opts)
+	return c.CallContext(context.Background(), method, endpoint, opts, resp)
+}
+
+// CallContext is Call with a context.Context that governs the call.
+func (c *Client) CallContext(ctx context.Context, method, endpoint string, opts *Optionals, resp interface{}) (err error) {
+	rawJSON, err := c.CallJSONContext(ctx, method, endpoint, opts)
 	if err != nil {
 		return
 	}
@@ -161,11 +309,16 @@ func (c *Client) Call(method, endpoint string, opts *Optionals, resp interface{}
 // parameter.
 // See https://dev.twitter.com/docs/api/1.1/get/statuses/mentions_timeline
 func (c *Client) Mentions(opts *Optionals) (tweets *TweetList, err error) {
+	return c.MentionsContext(context.Background(), opts)
+}
+
+// MentionsContext is Mentions with a context.Context that governs the call.
+func (c *Client) MentionsContext(ctx context.Context, opts *Optionals) (tweets *TweetList, err error) {
 	if opts == nil {
 		opts = NewOptionals()
 	}
 	tweets = &TweetList{}
-	err = c.Call("GET", "statuses/mentions_timeline", opts, tweets)
+	err = c.CallContext(ctx, "GET", "statuses/mentions_timeline", opts, tweets)
 	return
 }
 
@@ -173,12 +326,18 @@ func (c *Client) Mentions(opts *Optionals) (tweets *TweetList, err error) {
 // by the screen_name.
 // See https://dev.twitter.com/docs/api/1.1/get/statuses/user_timeline
 func (c *Client) UserTimeline(screenname string, opts *Optionals) (tweets *TweetList, err error) {
+	return c.UserTimelineContext(context.Background(), screenname, opts)
+}
+
+// UserTimelineContext is UserTimeline with a context.Context that governs
+// the call.
+func (c *Client) UserTimelineContext(ctx context.Context, screenname string, opts *Optionals) (tweets *TweetList, err error) {
 	if opts == nil {
 		opts = NewOptionals()
 	}
 	opts.Add("screen_name", screenname)
 	tweets = new(TweetList)
-	err = c.Call("GET", "statuses/user_timeline", opts, tweets)
+	err = c.CallContext(ctx, "GET", "statuses/user_timeline", opts, tweets)
 	return
 }
 
@@ -186,11 +345,17 @@ func (c *Client) UserTimeline(screenname string, opts *Optionals) (tweets *Tweet
 // the authenticating user and the users they follow.
 // See https://dev.twitter.com/docs/api/1.1/get/statuses/home_timeline
 func (c *Client) HomeTimeline(opts *Optionals) (tweets *TweetList, err error) {
+	return c.HomeTimelineContext(context.Background(), opts)
+}
+
+// HomeTimelineContext is HomeTimeline with a context.Context that governs
+// the call.
+func (c *Client) HomeTimelineContext(ctx context.Context, opts *Optionals) (tweets *TweetList, err error) {
 	if opts == nil {
 		opts = NewOptionals()
 	}
 	tweets = new(TweetList)
-	err = c.Call("GET", "statuses/home_timeline", opts, tweets)
+	err = c.CallContext(ctx, "GET", "statuses/home_timeline", opts, tweets)
 	return
 }
 
@@ -198,45 +363,69 @@ func (c *Client) HomeTimeline(opts *Optionals) (tweets *TweetList, err error) {
 // authenticating user that have been retweeted by others.
 // See https://dev.twitter.com/docs/api/1.1/get/statuses/retweets_of_me
 func (c *Client) RetweetsOfMe(opts *Optionals) (tweets *TweetList, err error) {
+	return c.RetweetsOfMeContext(context.Background(), opts)
+}
+
+// RetweetsOfMeContext is RetweetsOfMe with a context.Context that governs
+// the call.
+func (c *Client) RetweetsOfMeContext(ctx context.Context, opts *Optionals) (tweets *TweetList, err error) {
 	if opts == nil {
 		opts = NewOptionals()
 	}
 	tweets = new(TweetList)
-	err = c.Call("GET", "statuses/retweets_of_me", opts, tweets)
+	err = c.CallContext(ctx, "GET", "statuses/retweets_of_me", opts, tweets)
 	return
 }
 
-// Update: posts a status update to Twitter
+// Update: posts a status update to Twitter. To attach media uploaded
+// via client.Media.Upload, set opts.Add("media_ids", "id1,id2") (up to
+// four image IDs, or a single video/GIF ID).
 // See https://dev.twitter.com/docs/api/1.1/post/statuses/update
 func (c *Client) UpdateStatus(status string, opts *Optionals) (tweet *Tweet, err error) {
+	return c.UpdateStatusContext(context.Background(), status, opts)
+}
+
+// UpdateStatusContext is UpdateStatus with a context.Context that governs
+// the call.
+func (c *Client) UpdateStatusContext(ctx context.Context, status string, opts *Optionals) (tweet *Tweet, err error) {
 	if opts == nil {
 		opts = NewOptionals()
 	}
 	opts.Add("status", status)
 	tweet = &Tweet{}
-	err = c.Call("POST", "statuses/update", opts, tweet)
+	err = c.CallContext(ctx, "POST", "statuses/update", opts, tweet)
 	return tweet, err
 }
 
 // Returns up to 100 of the first retweets of a given tweet Id
 func (c *Client) Retweets(id int64, opts *Optionals) (tweets *TweetList, err error) {
+	return c.RetweetsContext(context.Background(), id, opts)
+}
+
+// RetweetsContext is Retweets with a context.Context that governs the call.
+func (c *Client) RetweetsContext(ctx context.Context, id int64, opts *Optionals) (tweets *TweetList, err error) {
 	if opts == nil {
 		opts = NewOptionals()
 	}
 	tweets = &TweetList{}
-	err = c.Call("GET", fmt.Sprintf("statuses/retweets/%d", id), opts, tweets)
+	err = c.CallContext(ctx, "GET", fmt.Sprintf("statuses/retweets/%d", id), opts, tweets)
 	return
 }
 
 // Returns a single Tweet, specified by the id parameter.
 // The Tweet's author will also be embedded within the tweet.
 func (c *Client) GetStatus(id int64, opts *Optionals) (tweet *Tweet, err error) {
+	return c.GetStatusContext(context.Background(), id, opts)
+}
+
+// GetStatusContext is GetStatus with a context.Context that governs the call.
+func (c *Client) GetStatusContext(ctx context.Context, id int64, opts *Optionals) (tweet *Tweet, err error) {
 	if opts == nil {
 		opts = NewOptionals()
 	}
 	opts.Add("id", id)
 	tweet = &Tweet{}
-	err = c.Call("GET", "statuses/show", opts, tweet)
+	err = c.CallContext(ctx, "GET", "statuses/show", opts, tweet)
 	return
 }
 
@@ -244,82 +433,67 @@ func (c *Client) GetStatus(id int64, opts *Optionals) (tweet *Tweet, err error)
 // The authenticating user must be the author of the specified
 // status. returns the destroyed tweet if successful
 func (c *Client) DestroyStatus(id int64, opts *Optionals) (tweet *Tweet, err error) {
+	return c.DestroyStatusContext(context.Background(), id, opts)
+}
+
+// DestroyStatusContext is DestroyStatus with a context.Context that governs
+// the call.
+func (c *Client) DestroyStatusContext(ctx context.Context, id int64, opts *Optionals) (tweet *Tweet, err error) {
 	if opts == nil {
 		opts = NewOptionals()
 	}
 	opts.Add("id", id)
 	tweet = &Tweet{}
-	err = c.Call("POST", fmt.Sprintf("statuses/destroy/%d", id), opts, tweet)
+	err = c.CallContext(ctx, "POST", fmt.Sprintf("statuses/destroy/%d", id), opts, tweet)
 	return tweet, err
 }
 
 // Retweets a tweet. Returns the original tweet with retweet details embedded.
 func (c *Client) Retweet(id int64, opts *Optionals) (tweet *Tweet, err error) {
+	return c.RetweetContext(context.Background(), id, opts)
+}
+
+// RetweetContext is Retweet with a context.Context that governs the call.
+func (c *Client) RetweetContext(ctx context.Context, id int64, opts *Optionals) (tweet *Tweet, err error) {
 	if opts == nil {
 		opts = NewOptionals()
 	}
 	opts.Add("id", id)
 	tweet = &Tweet{}
-	err = c.Call("POST", fmt.Sprintf("statuses/retweet/%d", id), opts, tweet)
+	err = c.CallContext(ctx, "POST", fmt.Sprintf("statuses/retweet/%d", id), opts, tweet)
 	return tweet, err
 }
 
-// Updates the authenticating user's current status and attaches media for
-// upload. In other words, it creates a Tweet with a picture attached.
-func (c *Client) UpdateStatusWithMedia(status string, media *TweetMedia, opts *Optionals) (tweet *Tweet, err error) {
-	if opts == nil {
-		opts = NewOptionals()
-	}
-
-	body := bytes.NewBufferString("")
-	mp := multipart.NewWriter(body)
-	mp.WriteField("status", status)
-	for n, v := range opts.Values {
-		mp.WriteField(n, v[0])
-	}
-	writer, err := mp.CreateFormFile("media[]", media.Filename)
-	if err != nil {
-		return nil, err
-	}
-	writer.Write(media.Data)
-	header := fmt.Sprintf("multipart/form-data;boundary=%v", mp.Boundary())
-	mp.Close()
-
-	endpoint := fmt.Sprintf("%s/statuses/update_with_media.json?%s", apiURL, opts.Values.Encode())
-	req, _ := http.NewRequest("POST", endpoint, body)
-	req.Header.Set("Content-Type", header)
-	res, err := c.client.Do(req)
-	if err != nil {
-		return
-	}
-	if err = checkResponse(res); err != nil {
-		return
-	}
-	if err = json.NewDecoder(res.Body).Decode(tweet); err != nil {
-		return
-	}
-	return
-
-}
-
 // Returns the current configuration used by Twitter including twitter.com
 // slugs which are not usernames, maximum photo resolutions, and t.co URL
 // lengths.
 // See https://dev.twitter.com/docs/api/1.1/get/help/configuration
 func (c *Client) Configuration() (configuration *Configuration, err error) {
+	return c.ConfigurationContext(context.Background())
+}
+
+// ConfigurationContext is Configuration with a context.Context that governs
+// the call.
+func (c *Client) ConfigurationContext(ctx context.Context) (configuration *Configuration, err error) {
 	configuration = &Configuration{}
-	err = c.Call("GET", "help/configuration", nil, configuration)
+	err = c.CallContext(ctx, "GET", "help/configuration", nil, configuration)
 	return
 }
 
 // Returns Twitter's Privacy Policy
 // https://dev.twitter.com/docs/api/1.1/get/help/privacy
 func (c *Client) PrivacyPolicy() (privacyPolicy string, err error) {
+	return c.PrivacyPolicyContext(context.Background())
+}
+
+// PrivacyPolicyContext is PrivacyPolicy with a context.Context that governs
+// the call.
+func (c *Client) PrivacyPolicyContext(ctx context.Context) (privacyPolicy string, err error) {
 	type pp struct {
 		Text string `json:"privacy"`
 	}
 	ret := &pp{}
-	err = c.Call("GET", "help/privacy", nil, ret)
+	err = c.CallContext(ctx, "GET", "help/privacy", nil, ret)
 	privacyPolicy = ret.Text
 	return
 }
@@ -327,19 +501,29 @@ func (c *Client) PrivacyPolicy() (privacyPolicy string, err error) {
 // Returns Twitter's terms of service
 // https://dev.twitter.com/docs/api/1.1/get/help/tos
 func (c *Client) Tos() (string, error) {
+	return c.TosContext(context.Background())
+}
+
+// TosContext is Tos with a context.Context that governs the call.
+func (c *Client) TosContext(ctx context.Context) (string, error) {
 	type tos struct {
 		Text string `json:"tos"`
 	}
 	ret := &tos{}
-	err := c.Call("GET", "help/tos", nil, ret)
+	err := c.CallContext(ctx, "GET", "help/tos", nil, ret)
 	return ret.Text, err
 }
 
 // Returns Twitter's terms of service
 // https://dev.twitter.com/docs/api/1.1/get/help/tos
 func (c *Client) Limits() (limits *Limits, err error) {
+	return c.LimitsContext(context.Background())
+}
+
+// LimitsContext is Limits with a context.Context that governs the call.
+func (c *Client) LimitsContext(ctx context.Context) (limits *Limits, err error) {
 	limits = &Limits{}
-	err = c.Call("GET", "application/rate_limit_status", nil, limits)
+	err = c.CallContext(ctx, "GET", "application/rate_limit_status", nil, limits)
 	return
 }
 
@@ -348,11 +532,16 @@ func (c *Client) Limits() (limits *Limits, err error) {
 // request up to 200 direct messages per call, up to a maximum of 800 incoming DMs
 // See https://dev.twitter.com/docs/api/1.1/get/direct_messages
 func (c *Client) DMList(opts *Optionals) (messages *MessageList, err error) {
+	return c.DMListContext(context.Background(), opts)
+}
+
+// DMListContext is DMList with a context.Context that governs the call.
+func (c *Client) DMListContext(ctx context.Context, opts *Optionals) (messages *MessageList, err error) {
 	if opts == nil {
 		opts = NewOptionals()
 	}
 	messages = &MessageList{}
-	err = c.Call("GET", "direct_messages", opts, messages)
+	err = c.CallContext(ctx, "GET", "direct_messages", opts, messages)
 	return
 }
 
@@ -361,23 +550,33 @@ func (c *Client) DMList(opts *Optionals) (messages *MessageList, err error) {
 // request up to 200 direct messages per call, up to a maximum of 800 outgoing DMs.
 // See https://dev.twitter.com/docs/api/1.1/get/direct_messages/sent
 func (c *Client) DMSent(opts *Optionals) (messages *MessageList, err error) {
+	return c.DMSentContext(context.Background(), opts)
+}
+
+// DMSentContext is DMSent with a context.Context that governs the call.
+func (c *Client) DMSentContext(ctx context.Context, opts *Optionals) (messages *MessageList, err error) {
 	if opts == nil {
 		opts = NewOptionals()
 	}
 	messages = &MessageList{}
-	err = c.Call("GET", "direct_messages/sent", opts, messages)
+	err = c.CallContext(ctx, "GET", "direct_messages/sent", opts, messages)
 	return
 }
 
 // Returns a single direct message, specified by an id parameter.
 // See https://dev.twitter.com/docs/api/1.1/get/direct_messages/show
 func (c *Client) DM(id int64, opts *Optionals) (message *Message, err error) {
+	return c.DMContext(context.Background(), id, opts)
+}
+
+// DMContext is DM with a context.Context that governs the call.
+func (c *Client) DMContext(ctx context.Context, id int64, opts *Optionals) (message *Message, err error) {
 	if opts == nil {
 		opts = NewOptionals()
 	}
 	opts.Add("id", id)
 	message = &Message{}
-	err = c.Call("GET", "direct_messages/show", opts, message)
+	err = c.CallContext(ctx, "GET", "direct_messages/show", opts, message)
 	return
 }
 
@@ -386,25 +585,35 @@ func (c *Client) DM(id int64, opts *Optionals) (message *Message, err error) {
 // message.
 // See https://dev.twitter.com/docs/api/1.1/post/direct_messages/destroy
 func (c *Client) DMDestroy(id int64, opts *Optionals) (message *Message, err error) {
+	return c.DMDestroyContext(context.Background(), id, opts)
+}
+
+// DMDestroyContext is DMDestroy with a context.Context that governs the call.
+func (c *Client) DMDestroyContext(ctx context.Context, id int64, opts *Optionals) (message *Message, err error) {
 	if opts == nil {
 		opts = NewOptionals()
 	}
 	opts.Add("id", id)
 	message = &Message{}
-	err = c.Call("POST", "direct_messages/show", opts, message)
+	err = c.CallContext(ctx, "POST", "direct_messages/show", opts, message)
 	return
 }
 
 // Sends a new direct message to the specified user from the authenticating user.
 // See https://dev.twitter.com/docs/api/1.1/post/direct_messages/new
 func (c *Client) DMSend(screenname, text string, opts *Optionals) (message *Message, err error) {
+	return c.DMSendContext(context.Background(), screenname, text, opts)
+}
+
+// DMSendContext is DMSend with a context.Context that governs the call.
+func (c *Client) DMSendContext(ctx context.Context, screenname, text string, opts *Optionals) (message *Message, err error) {
 	if opts == nil {
 		opts = NewOptionals()
 	}
 	opts.Add("screen_name", screenname)
 	opts.Add("text", text)
 	message = &Message{}
-	err = c.Call("POST", "direct_messages/new", opts, message)
+	err = c.CallContext(ctx, "POST", "direct_messages/new", opts, message)
 	return
 }
 
@@ -413,12 +622,17 @@ func (c *Client) DMSend(screenname, text string, opts *Optionals) (message *Mess
 // location, or other criteria. Exact match searches are not supported.
 // See https://dev.twitter.com/docs/api/1.1/get/users/search
 func (c *Client) Search(q string, opts *Optionals) (tweets *TweetList, err error) {
+	return c.SearchContext(context.Background(), q, opts)
+}
+
+// SearchContext is Search with a context.Context that governs the call.
+func (c *Client) SearchContext(ctx context.Context, q string, opts *Optionals) (tweets *TweetList, err error) {
 	if opts == nil {
 		opts = NewOptionals()
 	}
 	opts.Add("q", q)
 	tweets = &TweetList{}
-	err = c.Call("GET", "users/search", opts, tweets)
+	err = c.CallContext(ctx, "GET", "users/search", opts, tweets)
 	return
 }
 
@@ -426,8 +640,14 @@ func (c *Client) Search(q string, opts *Optionals) (tweets *TweetList, err error
 // for the authenticating user
 // See https://dev.twitter.com/docs/api/1.1/get/account/settings
 func (c *Client) AccountSettings() (settings *AccountSettings, err error) {
+	return c.AccountSettingsContext(context.Background())
+}
+
+// AccountSettingsContext is AccountSettings with a context.Context that
+// governs the call.
+func (c *Client) AccountSettingsContext(ctx context.Context) (settings *AccountSettings, err error) {
 	settings = &AccountSettings{}
-	err = c.Call("GET", "account/settings", nil, settings)
+	err = c.CallContext(ctx, "GET", "account/settings", nil, settings)
 	return
 }
 
@@ -435,35 +655,52 @@ func (c *Client) AccountSettings() (settings *AccountSettings, err error) {
 // user object if they are.
 // See https://dev.twitter.com/docs/api/1.1/get/account/verify_credentials
 func (c *Client) VerifyCredentials(opts *Optionals) (user *User, err error) {
+	return c.VerifyCredentialsContext(context.Background(), opts)
+}
+
+// VerifyCredentialsContext is VerifyCredentials with a context.Context that
+// governs the call.
+func (c *Client) VerifyCredentialsContext(ctx context.Context, opts *Optionals) (user *User, err error) {
 	if opts == nil {
 		opts = NewOptionals()
 	}
 	user = &User{}
-	err = c.Call("GET", "account/verify_credentials", opts, user)
+	err = c.CallContext(ctx, "GET", "account/verify_credentials", opts, user)
 	return
 }
 
 // Update authenticating user's settings.
 // See https://dev.twitter.com/docs/api/1.1/post/account/settings
 func (c *Client) UpdateSettings(opts *Optionals) (newSettings *AccountSettings, err error) {
+	return c.UpdateSettingsContext(context.Background(), opts)
+}
+
+// UpdateSettingsContext is UpdateSettings with a context.Context that
+// governs the call.
+func (c *Client) UpdateSettingsContext(ctx context.Context, opts *Optionals) (newSettings *AccountSettings, err error) {
 	if opts == nil {
 		opts = NewOptionals()
 	}
 	newSettings = &AccountSettings{}
-	err = c.Call("POST", "account/settings", opts, newSettings)
+	err = c.CallContext(ctx, "POST", "account/settings", opts, newSettings)
 	return
 }
 
 // Enables/disables SMS delivery
 // See https://dev.twitter.com/docs/api/1.1/post/account/update_delivery_device
 func (c *Client) EnableSMS(enable bool) (err error) {
+	return c.EnableSMSContext(context.Background(), enable)
+}
+
+// EnableSMSContext is EnableSMS with a context.Context that governs the call.
+func (c *Client) EnableSMSContext(ctx context.Context, enable bool) (err error) {
 	opts := NewOptionals()
 	if enable {
 		opts.Add("device", "sms")
 	} else {
 		opts.Add("device", "none")
 	}
-	err = c.Call("POST", "account/update_delivery_device", opts, nil)
+	err = c.CallContext(ctx, "POST", "account/update_delivery_device", opts, nil)
 	return
 }
 
@@ -471,11 +708,17 @@ func (c *Client) EnableSMS(enable bool) (err error) {
 // settings page. Only the parameters specified will be updated.
 // See https://dev.twitter.com/docs/api/1.1/post/account/update_profile
 func (c *Client) UpdateProfile(opts *Optionals) (user *User, err error) {
+	return c.UpdateProfileContext(context.Background(), opts)
+}
+
+// UpdateProfileContext is UpdateProfile with a context.Context that governs
+// the call.
+func (c *Client) UpdateProfileContext(ctx context.Context, opts *Optionals) (user *User, err error) {
 	if opts == nil {
 		opts = NewOptionals()
 	}
 	user = &User{}
-	err = c.Call("POST", "account/update_profile", opts, user)
+	err = c.CallContext(ctx, "POST", "account/update_profile", opts, user)
 	return
 }
 
@@ -484,6 +727,12 @@ func (c *Client) UpdateProfile(opts *Optionals) (user *User, err error) {
 // background image.
 // https://dev.twitter.com/docs/api/1.1/post/account/update_profile_background_image
 func (c *Client) UpdateProfileBackgroundImage(image []byte, opts *Optionals) (user *User, err error) {
+	return c.UpdateProfileBackgroundImageContext(context.Background(), image, opts)
+}
+
+// UpdateProfileBackgroundImageContext is UpdateProfileBackgroundImage with a
+// context.Context that governs the call.
+func (c *Client) UpdateProfileBackgroundImageContext(ctx context.Context, image []byte, opts *Optionals) (user *User, err error) {
 	if opts == nil {
 		opts = NewOptionals()
 	}
@@ -494,7 +743,6 @@ func (c *Client) UpdateProfileBackgroundImage(image []byte, opts *Optionals) (us
 		opts.Add("use", false)
 	}
 	user = &User{}
-	err = c.Call("POST", "account/update_profile_background_image", opts, user)
+	err = c.CallContext(ctx, "POST", "account/update_profile_background_image", opts, user)
 	return
-
 }